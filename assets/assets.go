@@ -1,14 +1,14 @@
 package assets
 
 import (
-	_ "embed"
+	"embed"
 )
 
-//go:embed level1.txt
-var Level1 string
+//go:embed campaign.json
+var Campaign []byte
 
-//go:embed level2.txt
-var Level2 string
+//go:embed level*
+var Levels embed.FS
 
 //go:embed TileMiddle.png
 var GroundMid []byte
@@ -55,6 +55,78 @@ var GroundFillDeeper2 []byte
 //go:embed TileFillDeeper3.png
 var GroundFillDeeper3 []byte
 
+//go:embed Spikes.png
+var Spikes []byte
+
+//go:embed Lava.png
+var Lava []byte
+
+//go:embed Water.png
+var Water []byte
+
+//go:embed Coin.png
+var Coin []byte
+
+//go:embed Gem.png
+var Gem []byte
+
+//go:embed Platform.png
+var Platform []byte
+
+//go:embed PlatformOneWay.png
+var PlatformOneWay []byte
+
+//go:embed OneWayLeft.png
+var OneWayLeft []byte
+
+//go:embed OneWayMid.png
+var OneWayMid []byte
+
+//go:embed OneWayRight.png
+var OneWayRight []byte
+
+//go:embed OneWaySingle.png
+var OneWaySingle []byte
+
+//go:embed "tiles/1 Tiles/Tile_43.png"
+var SlopeUp []byte
+
+//go:embed "tiles/1 Tiles/Tile_42.png"
+var SlopeDown []byte
+
+//go:embed "tiles/1 Tiles/Tile_55.png"
+var SlopeUpFill []byte
+
+//go:embed "tiles/1 Tiles/Tile_54.png"
+var SlopeDownFill []byte
+
+//go:embed Ladder.png
+var Ladder []byte
+
+//go:embed Key.png
+var Key []byte
+
+//go:embed Door.png
+var Door []byte
+
+//go:embed DoorOpen.png
+var DoorOpen []byte
+
+//go:embed Switch.png
+var Switch []byte
+
+//go:embed SwitchPressed.png
+var SwitchPressed []byte
+
+//go:embed LeverOff.png
+var LeverOff []byte
+
+//go:embed LeverOn.png
+var LeverOn []byte
+
+//go:embed Block.png
+var Block []byte
+
 //go:embed BackgroundImage.png
 var BackgroundImage []byte
 
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a level file. Line and Column start at 1 and are 0 for problems of the whole
+// file, Column is also 0 for problems of a whole line. For ASCII levels they point at the character in the file,
+// for other formats at the row and column of the tile.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+	if d.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// report collects the diagnostics of one file.
+type report struct {
+	file        string
+	diagnostics []Diagnostic
+}
+
+// at adds a diagnostic for the cell x, y, cells are one character each so the position is the same in every format.
+// An x of -1 is the whole line.
+func (r *report) at(x, y int, severity Severity, format string, args ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		File:     r.file,
+		Line:     y + 1,
+		Column:   x + 1,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// whole adds a diagnostic for the whole file.
+func (r *report) whole(severity Severity, format string, args ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		File:     r.file,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkFile parses a level file in any format levelmap.Load reads and checks it.
+func checkFile(name string, arcs []arc) []Diagnostic {
+	r := &report{file: name}
+
+	var data []byte
+	var m *levelmap.Map
+	var err error
+	switch filepath.Ext(name) {
+	case ".tmx", ".tmj":
+		m, err = levelmap.LoadTiled(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+	default:
+		if data, err = os.ReadFile(name); err == nil {
+			m, err = levelmap.Parse(string(data))
+		}
+	}
+	if err != nil {
+		// the position goes into the diagnostic, the message keeps it only when the error was wrapped with more
+		var parseErr *levelmap.ParseError
+		switch {
+		case errors.As(err, &parseErr) && err == error(parseErr):
+			r.at(parseErr.Column, parseErr.Line, SeverityError, "%v", parseErr.Err)
+		case errors.As(err, &parseErr):
+			r.at(parseErr.Column, parseErr.Line, SeverityError, "%v", err)
+		default:
+			r.whole(SeverityError, "%v", err)
+		}
+		return r.diagnostics
+	}
+
+	if data != nil && !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		checkUnknownChars(r, string(data), m)
+	}
+	checkMap(r, m, arcs)
+	return r.diagnostics
+}
+
+// checkUnknownChars reports the characters of an ASCII level that ParseASCII leaves empty because it does not know
+// them: cells that hold a character other than a space but neither terrain nor an object.
+func checkUnknownChars(r *report, data string, m *levelmap.Map) {
+	objects := map[[2]int]bool{}
+	for _, o := range m.Objects {
+		objects[[2]int{o.X, o.Y}] = true
+	}
+
+	// the same lines ParseASCII reads
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for y := 0; y < m.Height(); y++ {
+		for x, char := range lines[y] {
+			if char != ' ' && m.Get(x, y) == levelmap.Space && !objects[[2]int{x, y}] {
+				r.at(x, y, SeverityWarning, "unknown character %q is left empty", char)
+			}
+		}
+	}
+}
+
+// checkMap reports what makes the level unplayable, Level.Build needs a player and a goal.
+func checkMap(r *report, m *levelmap.Map, arcs []arc) {
+	players := m.ObjectsOf(levelmap.ObjectPlayer)
+	goals := m.ObjectsOf(levelmap.ObjectGoal)
+	if len(players) == 0 {
+		r.whole(SeverityError, "level has no player %q", levelmap.Player)
+	}
+	if len(goals) == 0 {
+		r.whole(SeverityError, "level has no goal %q", levelmap.Goal)
+	}
+
+	// npcs do not fall, one placed in the air floats there walking into nothing
+	for _, npc := range m.ObjectsOf(levelmap.ObjectNpc) {
+		if !floor(m, npc.X, npc.Y+1) && !levelmap.IsSlope(m.Get(npc.X, npc.Y)) {
+			r.at(npc.X, npc.Y, SeverityError, "npc has no floor under it")
+		}
+	}
+
+	if len(players) == 0 || len(goals) == 0 {
+		return
+	}
+	player := players[0]
+	touched := reachable(m, player.X, player.Y, arcs)
+	for _, goal := range goals {
+		if touched[goal.Y][goal.X] {
+			continue
+		}
+		msg := "goal cannot be reached from the player"
+		if hasPlatforms(m) {
+			// moving platforms and what they carry the player to are not part of the analysis
+			r.at(goal.X, goal.Y, SeverityWarning, "%s without riding moving platforms", msg)
+		} else {
+			r.at(goal.X, goal.Y, SeverityError, "%s", msg)
+		}
+	}
+}
+
+func hasPlatforms(m *levelmap.Map) bool {
+	for _, line := range m.Tiles {
+		for _, c := range line {
+			if c == levelmap.Platform || c == levelmap.OneWayPlatform {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/physics"
+)
+
+// check writes the rows of an ASCII level to a file and checks it with the default physics.
+func check(t *testing.T, rows ...string) []Diagnostic {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "level.txt")
+	if err := os.WriteFile(name, []byte(strings.Join(rows, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := checkFile(name, arcs(physics.DefaultPlayer()))
+	for i := range diagnostics {
+		diagnostics[i].File = "level.txt"
+	}
+	return diagnostics
+}
+
+func messages(diagnostics []Diagnostic) []string {
+	var lines []string
+	for _, d := range diagnostics {
+		lines = append(lines, d.String())
+	}
+	return lines
+}
+
+func expect(t *testing.T, name string, diagnostics []Diagnostic, want ...string) {
+	t.Helper()
+	got := messages(diagnostics)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s: got diagnostics %q, want %q", name, got, want)
+	}
+}
+
+func TestCheckPlayerAndGoal(t *testing.T) {
+	expect(t, "no player", check(t,
+		"X     X",
+		"X    GX",
+		"XXXXXXX",
+	), `level.txt: error: level has no player 'P'`)
+	expect(t, "no goal", check(t,
+		"X     X",
+		"XP    X",
+		"XXXXXXX",
+	), `level.txt: error: level has no goal 'G'`)
+	expect(t, "neither", check(t,
+		"X     X",
+		"X     X",
+		"XXXXXXX",
+	), `level.txt: error: level has no player 'P'`, `level.txt: error: level has no goal 'G'`)
+	expect(t, "both", check(t,
+		"X     X",
+		"XP   GX",
+		"XXXXXXX",
+	))
+}
+
+func TestCheckPositions(t *testing.T) {
+	expect(t, "unknown character", check(t,
+		"X  ?  X",
+		"XP   GX",
+		"XXXXXXX",
+	), `level.txt:1:4: warning: unknown character '?' is left empty`)
+	expect(t, "npc in the air", check(t,
+		"X     X",
+		"X  R  X",
+		"X     X",
+		"XP   GX",
+		"XXXXXXX",
+	), `level.txt:2:4: error: npc has no floor under it`)
+	expect(t, "npc on the ground", check(t,
+		"X     X",
+		"XP R GX",
+		"XXXXXXX",
+	))
+	expect(t, "parse error", check(t,
+		"X     X",
+		"XP  PGX",
+		"XXXXXXX",
+	), `level.txt:2:5: error: second player declaration`)
+	expect(t, "ragged line", check(t,
+		"X     X",
+		"XP   GX",
+		"XXXXXX",
+	), `level.txt:3: error: the width of the lvl must be same, the line has a width of 6 expected 7`)
+}
+
+// gap is a level with a pit of width cells between the ledge of the player and the one of the goal.
+func gap(width int) []string {
+	space := strings.Repeat(" ", width)
+	return []string{
+		"X" + strings.Repeat(" ", width+8) + "X",
+		"X" + strings.Repeat(" ", width+8) + "X",
+		"X  P " + space + " G  X",
+		"XXXXX" + space + "XXXXX",
+	}
+}
+
+func TestCheckGap(t *testing.T) {
+	expect(t, "widest gap", check(t, gap(5)...))
+	expect(t, "gap too wide", check(t, gap(6)...), `level.txt:3:13: error: goal cannot be reached from the player`)
+}
+
+func TestCheckLadder(t *testing.T) {
+	// the ledge of the goal is three cells up, higher than a jump
+	level := []string{
+		"X          X",
+		"X     G    X",
+		"X    HXXXXXX",
+		"X    H     X",
+		"X P  H     X",
+		"XXXXXXXXXXXX",
+	}
+	expect(t, "ladder", check(t, level...))
+	for i := range level {
+		level[i] = strings.ReplaceAll(level[i], "H", " ")
+	}
+	expect(t, "no ladder", check(t, level...), `level.txt:2:7: error: goal cannot be reached from the player`)
+}
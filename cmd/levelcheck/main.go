@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/physics"
+)
+
+func main() {
+	if err := realMain(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// realMain checks the level files given as arguments and prints a diagnostic per problem found, it fails when any
+// level has errors.
+func realMain() error {
+	physicsFile := flag.String("physics", "", "player physics profile the reachability check jumps with, the default physics without one")
+	asJSON := flag.Bool("json", false, "print the diagnostics as one json object per line")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		return fmt.Errorf("usage: levelcheck [-physics file] [-json] level...")
+	}
+
+	phys := physics.DefaultPlayer()
+	if *physicsFile != "" {
+		var err error
+		if phys, err = physics.LoadPlayer(*physicsFile); err != nil {
+			return err
+		}
+	}
+	jumps := arcs(phys)
+
+	failed := 0
+	for _, name := range flag.Args() {
+		diagnostics := checkFile(name, jumps)
+		for _, d := range diagnostics {
+			if *asJSON {
+				line, err := json.Marshal(d)
+				if err != nil {
+					return fmt.Errorf("cant encode diagnostic %w", err)
+				}
+				fmt.Println(string(line))
+			} else {
+				fmt.Println(d)
+			}
+		}
+		if hasErrors(diagnostics) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d levels have errors", failed, flag.NArg())
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"math"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/physics"
+)
+
+const (
+	// arcTicks is how long a jump or a fall is followed, long enough to fall through any level.
+	arcTicks = 300
+	// tickRate matches game.TickRate.
+	tickRate = 60
+)
+
+// arc is the path of the feet of the player, tick by tick from where it leaves the ground, in unscaled pixels.
+// Ledge arcs walk off a ledge and jump at the end of the coyote time.
+type arc struct {
+	jump  bool
+	ledge bool
+	vx    float64
+	steps []arcStep
+}
+
+type arcStep struct {
+	x, y    float64
+	falling bool
+}
+
+// arcs integrates the jumps the player can make the way Player.Movement does at scale 1: every held jump length,
+// from the ground and at the end of the coyote time, and falls off ledges, each at a few horizontal speeds in both
+// directions.
+func arcs(p physics.Player) []arc {
+	coyote := int(math.Ceil(p.CoyoteTime*tickRate)) - 1
+	var all []arc
+	for k := -4; k <= 4; k++ {
+		vx := p.Speed * float64(k) / 4
+		all = append(all, jumpArc(p, false, 0, 0, vx))
+		for release := 1; release <= p.JumpTicks+1; release++ {
+			all = append(all, jumpArc(p, true, release, 0, vx))
+			if coyote > 0 && k != 0 {
+				ledge := jumpArc(p, true, release, coyote, vx)
+				ledge.ledge = true
+				all = append(all, ledge)
+			}
+		}
+	}
+	return all
+}
+
+// jumpArc follows a jump held for release ticks, counting the tick it starts, after falling for delay ticks, or a
+// fall when jump is false. A buffered jump rises like one held to the end.
+func jumpArc(p physics.Player, jump bool, release, delay int, vx float64) arc {
+	a := arc{jump: jump, vx: vx}
+	jumping := false
+	duration := 0
+	x, y, vy := 0.0, 0.0, 0.0
+	for tick := 0; tick < arcTicks; tick++ {
+		if jump && tick == delay {
+			// the first impulse is given on the tick the jump starts
+			jumping = true
+			duration = 1
+			vy = -p.JumpImpulse
+		} else {
+			if jumping {
+				duration++
+				if duration <= release {
+					vy -= p.JumpImpulse
+				} else {
+					jumping = false
+					if vy < 0 {
+						vy *= p.JumpCutoff
+					}
+				}
+				if duration > p.JumpTicks {
+					jumping = false
+				}
+			} else if vy < p.TerminalVelocity {
+				vy += p.Gravity
+			}
+		}
+		x += vx
+		y += vy
+		a.steps = append(a.steps, arcStep{x: x, y: y, falling: vy > 0})
+	}
+	return a
+}
+
+func inside(m *levelmap.Map, x, y int) bool {
+	return x >= 0 && x < m.Width() && y < m.Height()
+}
+
+func hazard(c levelmap.Component) bool {
+	switch c {
+	case levelmap.Spikes, levelmap.Lava, levelmap.Water, levelmap.KillZone:
+		return true
+	}
+	return false
+}
+
+// passable reports whether the player can be in the cell, above the level counts as open. Doors, blocks and
+// moving platforms are left out, keys and switches can change them.
+func passable(m *levelmap.Map, x, y int) bool {
+	if !inside(m, x, y) {
+		return false
+	}
+	c := m.Get(x, y)
+	return c != levelmap.Ground && !hazard(c)
+}
+
+// floor reports whether the player can stand on top of the cell.
+func floor(m *levelmap.Map, x, y int) bool {
+	if !inside(m, x, y) || y < 0 {
+		return false
+	}
+	c := m.Get(x, y)
+	return c == levelmap.Ground || c == levelmap.OneWay || c == levelmap.Ladder || levelmap.IsSlope(c)
+}
+
+// standable reports whether the player can stay in the cell, on a floor, a slope or holding a ladder.
+func standable(m *levelmap.Map, x, y int) bool {
+	if !passable(m, x, y) || y < 0 {
+		return false
+	}
+	c := m.Get(x, y)
+	return levelmap.IsSlope(c) || c == levelmap.Ladder || floor(m, x, y+1)
+}
+
+// reachable searches the cells the player can stand in starting from the spawn, walking, climbing and following
+// the jump arcs. It returns every cell the player passes through on the way.
+func reachable(m *levelmap.Map, startX, startY int, arcs []arc) [][]bool {
+	touched := make([][]bool, m.Height())
+	visited := make([][]bool, m.Height())
+	for y := range touched {
+		touched[y] = make([]bool, m.Width())
+		visited[y] = make([]bool, m.Width())
+	}
+
+	var queue [][2]int
+	visit := func(x, y int) {
+		if !visited[y][x] {
+			visited[y][x] = true
+			touched[y][x] = true
+			queue = append(queue, [2]int{x, y})
+		}
+	}
+	launch := func(x, y int, jump, ledge bool, dir int) {
+		for _, a := range arcs {
+			if a.jump != jump || a.ledge != ledge || dir != 0 && a.vx*float64(dir) < 0 {
+				continue
+			}
+			// ledge arcs start at the edge the player walks off
+			startX := (float64(x) + 0.5) * levelmap.CellSize
+			if ledge {
+				startX += float64(dir) * levelmap.CellSize / 2
+			}
+			follow(m, startX, float64(y+1)*levelmap.CellSize, a, touched, visit)
+		}
+	}
+
+	touched[startY][startX] = true
+	if standable(m, startX, startY) {
+		visit(startX, startY)
+	} else {
+		launch(startX, startY, false, false, 0)
+	}
+
+	for len(queue) > 0 {
+		x, y := queue[0][0], queue[0][1]
+		queue = queue[1:]
+
+		for _, dir := range []int{-1, 1} {
+			nx := x + dir
+			switch {
+			case standable(m, nx, y):
+				visit(nx, y)
+			case passable(m, nx, y):
+				launch(nx, y, false, false, dir)
+				launch(x, y, true, true, dir)
+			case levelmap.IsSlope(m.Get(x, y)) && standable(m, nx, y-1):
+				// walking up off the high end of a slope
+				visit(nx, y-1)
+			}
+		}
+		if m.Get(x, y) == levelmap.Ladder && standable(m, x, y-1) {
+			visit(x, y-1)
+		}
+		if m.Get(x, y+1) == levelmap.Ladder {
+			visit(x, y+1)
+		}
+		launch(x, y, true, false, 0)
+	}
+	return touched
+}
+
+// follow moves the feet of the player along the arc from startX, startY until it lands, hits something or falls
+// out of the level. The player is a cell tall and is checked at the column of its center.
+func follow(m *levelmap.Map, startX, startY float64, a arc, touched [][]bool, visit func(x, y int)) {
+	row := int(math.Floor((startY - 1) / levelmap.CellSize))
+	for _, step := range a.steps {
+		fx, fy := startX+step.x, startY+step.y
+		cx := int(math.Floor(fx / levelmap.CellSize))
+		cy := int(math.Floor((fy - 1) / levelmap.CellSize))
+		head := int(math.Floor((fy - levelmap.CellSize) / levelmap.CellSize))
+		if cy >= m.Height() {
+			return
+		}
+
+		if step.falling && cy > row && floor(m, cx, cy) && !levelmap.IsSlope(m.Get(cx, cy)) {
+			if standable(m, cx, cy-1) {
+				visit(cx, cy-1)
+			}
+			return
+		}
+		if !passable(m, cx, cy) || !passable(m, cx, head) {
+			return
+		}
+		if cy >= 0 {
+			touched[cy][cx] = true
+		}
+		if head >= 0 {
+			touched[head][cx] = true
+		}
+		if c := m.Get(cx, cy); cy >= 0 && (levelmap.IsSlope(c) || c == levelmap.Ladder) {
+			// a slope catches the player, a ladder can be grabbed on the way
+			visit(cx, cy)
+			if levelmap.IsSlope(c) {
+				return
+			}
+		}
+		row = cy
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+func main() {
+	if err := realMain(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// realMain renders a level file to a png without opening a window.
+func realMain() error {
+	out := flag.String("o", "", "png file to write, the level file name with a .png extension by default")
+	cellSize := flag.Int("cell", int(levelmap.CellSize), "size of a level cell in pixels, small values give thumbnails")
+	seed := flag.Int64("seed", 1, "seed of the random deep ground variants")
+	markers := flag.Bool("markers", true, "mark the spawn, the goal, the npcs and their patrol ranges")
+	flag.Parse()
+	if flag.NArg() != 1 || *cellSize < 1 {
+		return fmt.Errorf("usage: levelpng [-o file] [-cell pixels] [-seed n] [-markers=false] level")
+	}
+
+	name := flag.Arg(0)
+	m, err := levelmap.Load(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	img, err := Render(m, *cellSize, *seed, *markers)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(name, filepath.Ext(name)) + ".png"
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("cant create preview %w", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("cant write preview %s %w", *out, err)
+	}
+	return file.Close()
+}
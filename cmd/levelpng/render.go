@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/png"
+	"math"
+	"math/rand"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/assets"
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+	xdraw "golang.org/x/image/draw"
+)
+
+// tileImages are the images of the tiles a levelmap names, the same the game loads for them.
+var tileImages = map[levelmap.Tile][]byte{
+	levelmap.TileGroundMid:            assets.GroundMid,
+	levelmap.TileGroundLeft:           assets.GroundLeft,
+	levelmap.TileGroundRight:          assets.GroundRight,
+	levelmap.TileGroundSingle:         assets.GroundSingle,
+	levelmap.TileGroundFill:           assets.GroundFill,
+	levelmap.TileGroundFillSingle:     assets.GroundFillSingle,
+	levelmap.TileGroundFillLeft:       assets.GroundFillLeft,
+	levelmap.TileGroundFillRight:      assets.GroundFillRight,
+	levelmap.TileGroundFillDeep:       assets.GroundFillDeep,
+	levelmap.TileGroundFillDeepSingle: assets.GroundFillDeepSingle,
+	levelmap.TileGroundFillDeepLeft:   assets.GroundFillDeepLeft,
+	levelmap.TileGroundFillDeepRight:  assets.GroundFillDeepRight,
+	levelmap.TileGroundFillDeeper:     assets.GroundFillDeeper,
+	levelmap.TileGroundFillDeeper2:    assets.GroundFillDeeper2,
+	levelmap.TileGroundFillDeeper3:    assets.GroundFillDeeper3,
+	levelmap.TileOneWayLeft:           assets.OneWayLeft,
+	levelmap.TileOneWayMid:            assets.OneWayMid,
+	levelmap.TileOneWayRight:          assets.OneWayRight,
+	levelmap.TileOneWaySingle:         assets.OneWaySingle,
+	levelmap.TileSlopeUp:              assets.SlopeUp,
+	levelmap.TileSlopeDown:            assets.SlopeDown,
+	levelmap.TileSlopeUpFill:          assets.SlopeUpFill,
+	levelmap.TileSlopeDownFill:        assets.SlopeDownFill,
+	levelmap.TileLadder:               assets.Ladder,
+	levelmap.TileSpikes:               assets.Spikes,
+	levelmap.TileLava:                 assets.Lava,
+	levelmap.TileWater:                assets.Water,
+	levelmap.TilePlatform:             assets.Platform,
+	levelmap.TilePlatformOneWay:       assets.PlatformOneWay,
+	levelmap.TileCoin:                 assets.Coin,
+	levelmap.TileGem:                  assets.Gem,
+	levelmap.TileKey:                  assets.Key,
+	levelmap.TileDoor:                 assets.Door,
+	levelmap.TileDoorOpen:             assets.DoorOpen,
+	levelmap.TileSwitch:               assets.Switch,
+	levelmap.TileSwitchPressed:        assets.SwitchPressed,
+	levelmap.TileLeverOff:             assets.LeverOff,
+	levelmap.TileLeverOn:              assets.LeverOn,
+	levelmap.TileBlock:                assets.Block,
+}
+
+// objectImages are the images Level.Build draws the objects with, the player is only marked.
+var objectImages = map[levelmap.ObjectType][]byte{
+	levelmap.ObjectGoal:       assets.ButtonNoText,
+	levelmap.ObjectNpc:        assets.ButtonContinue,
+	levelmap.ObjectCheckpoint: assets.ButtonOff,
+	levelmap.ObjectCoin:       assets.Coin,
+	levelmap.ObjectGem:        assets.Gem,
+	levelmap.ObjectKey:        assets.Key,
+	levelmap.ObjectDoor:       assets.Door,
+	levelmap.ObjectSwitch:     assets.Switch,
+	levelmap.ObjectLever:      assets.LeverOff,
+	levelmap.ObjectBlock:      assets.Block,
+}
+
+var (
+	skyColor    = color.RGBA{0x8c, 0xc4, 0xe6, 0xff}
+	killColor   = color.NRGBA{0x60, 0x00, 0x00, 0x60}
+	spawnColor  = color.RGBA{0x20, 0xc0, 0x40, 0xff}
+	goalColor   = color.RGBA{0xf0, 0xc0, 0x20, 0xff}
+	npcColor    = color.RGBA{0xe0, 0x30, 0x30, 0xff}
+	patrolColor = color.NRGBA{0xe0, 0x30, 0x30, 0x80}
+)
+
+// renderer draws a level into an image, areas are in unscaled level pixels.
+type renderer struct {
+	dst    *image.RGBA
+	scale  float64
+	cache  map[string]image.Image
+	scaler xdraw.Scaler
+}
+
+// Render draws the whole level with cellSize pixels per cell. The terrain is autotiled exactly like Level.Build
+// does with the same seed, moving platforms are drawn where they start. With markers the spawn, the goal, the npcs
+// and the ranges they patrol are outlined.
+func Render(m *levelmap.Map, cellSize int, seed int64, markers bool) (*image.RGBA, error) {
+	r := &renderer{
+		dst:   image.NewRGBA(image.Rect(0, 0, m.Width()*cellSize, m.Height()*cellSize)),
+		scale: float64(cellSize) / levelmap.CellSize,
+		cache: map[string]image.Image{},
+		// pixel art stays sharp when enlarged, thumbnails are smoothed
+		scaler: xdraw.NearestNeighbor,
+	}
+	if r.scale < 1 {
+		r.scaler = xdraw.ApproxBiLinear
+	}
+	draw.Draw(r.dst, r.dst.Bounds(), image.NewUniform(skyColor), image.Point{}, draw.Src)
+
+	// the same order Level.Build takes the random deep fill variants in
+	rng := rand.New(rand.NewSource(seed))
+	for y, line := range m.Tiles {
+		for x, component := range line {
+			cell := levelmap.Cell(x, y)
+			for _, sprite := range m.TerrainSprites(x, y, rng) {
+				if err := r.tile(sprite.Tile, sprite.Area); err != nil {
+					return nil, err
+				}
+			}
+
+			var err error
+			switch component {
+			case levelmap.Spikes:
+				err = r.tile(levelmap.TileSpikes, cell)
+			case levelmap.Lava:
+				err = r.tile(levelmap.TileLava, cell)
+			case levelmap.Water:
+				err = r.tile(levelmap.TileWater, cell)
+			case levelmap.KillZone:
+				if markers {
+					r.fill(cell, killColor)
+				}
+			case levelmap.Platform, levelmap.OneWayPlatform:
+				if width := m.PlatformRun(x, y); width > 0 {
+					tile := levelmap.TilePlatform
+					if component == levelmap.OneWayPlatform {
+						tile = levelmap.TilePlatformOneWay
+					}
+					area := cell
+					area.Right = cell.Left + float64(width)*levelmap.CellSize
+					area.Bottom = cell.Top + levelmap.ThinHeight
+					err = r.tile(tile, area)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for y, line := range m.Decor {
+		for x, tile := range line {
+			if tile == "" {
+				continue
+			}
+			if err := r.tile(tile, levelmap.Cell(x, y)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, o := range m.Objects {
+		data, ok := objectImages[o.Type]
+		if !ok || o.Type == levelmap.ObjectBlock && o.Props.Off {
+			continue
+		}
+		if err := r.stretch(string(o.Type), data, levelmap.Cell(o.X, o.Y)); err != nil {
+			return nil, fmt.Errorf("cant draw %s %w", o.Type, err)
+		}
+	}
+
+	if markers {
+		r.markers(m)
+	}
+	return r.dst, nil
+}
+
+// markers outlines the spawn, the goal and the npcs and draws a bar under the cells each npc patrols, the range
+// Level.NewNpcObj gives it from GroundGroup or its patrol properties.
+func (r *renderer) markers(m *levelmap.Map) {
+	width := math.Max(1, 3*r.scale)
+	for _, o := range m.Objects {
+		cell := levelmap.Cell(o.X, o.Y)
+		switch o.Type {
+		case levelmap.ObjectPlayer:
+			r.outline(cell, spawnColor, width)
+		case levelmap.ObjectGoal:
+			r.outline(cell, goalColor, width)
+		case levelmap.ObjectNpc:
+			left, right := m.GroundGroup(o.X, o.Y)
+			if o.Props.PatrolLeft != nil {
+				left = *o.Props.PatrolLeft
+			}
+			if o.Props.PatrolRight != nil {
+				right = *o.Props.PatrolRight
+			}
+			patrol := levelmap.Cell(o.X-left, o.Y)
+			patrol.Right = levelmap.Cell(o.X+right, o.Y).Right
+			patrol.Top = patrol.Bottom - 8
+			r.fill(patrol, patrolColor)
+			r.outline(cell, npcColor, width)
+		}
+	}
+}
+
+func (r *renderer) rect(area levelmap.Rect) image.Rectangle {
+	return image.Rect(
+		int(math.Round(area.Left*r.scale)),
+		int(math.Round(area.Top*r.scale)),
+		int(math.Round(area.Right*r.scale)),
+		int(math.Round(area.Bottom*r.scale)),
+	)
+}
+
+func (r *renderer) tile(tile levelmap.Tile, area levelmap.Rect) error {
+	data, ok := tileImages[tile]
+	if !ok {
+		return fmt.Errorf("no image for tile %q", tile)
+	}
+	if err := r.stretch("tile "+string(tile), data, area); err != nil {
+		return fmt.Errorf("cant draw tile %q %w", tile, err)
+	}
+	return nil
+}
+
+// stretch draws the png data over area like the game stretches textures over their objects, the decoded image is
+// kept under name.
+func (r *renderer) stretch(name string, data []byte, area levelmap.Rect) error {
+	src, ok := r.cache[name]
+	if !ok {
+		var err error
+		if src, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("cant decode image %w", err)
+		}
+		r.cache[name] = src
+	}
+	r.scaler.Scale(r.dst, r.rect(area), src, src.Bounds(), draw.Over, nil)
+	return nil
+}
+
+func (r *renderer) fill(area levelmap.Rect, c color.Color) {
+	draw.Draw(r.dst, r.rect(area), image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// outline draws a frame of the given width in image pixels inside area.
+func (r *renderer) outline(area levelmap.Rect, c color.Color, width float64) {
+	w := width / r.scale
+	r.fill(levelmap.Rect{Left: area.Left, Top: area.Top, Right: area.Right, Bottom: area.Top + w}, c)
+	r.fill(levelmap.Rect{Left: area.Left, Top: area.Bottom - w, Right: area.Right, Bottom: area.Bottom}, c)
+	r.fill(levelmap.Rect{Left: area.Left, Top: area.Top + w, Right: area.Left + w, Bottom: area.Bottom - w}, c)
+	r.fill(levelmap.Rect{Left: area.Right - w, Top: area.Top + w, Right: area.Right, Bottom: area.Bottom - w}, c)
+}
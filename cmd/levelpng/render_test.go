@@ -0,0 +1,62 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+func TestRenderLevel1(t *testing.T) {
+	data, err := os.ReadFile("../../assets/level1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := levelmap.Parse(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const cell = 10
+	img, err := Render(m, cell, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := image.Rect(0, 0, m.Width()*cell, m.Height()*cell); img.Bounds() != want {
+		t.Fatalf("rendered %v, want %v", img.Bounds(), want)
+	}
+	// center is the middle pixel of the cell x, y
+	center := func(x, y int) color.RGBA {
+		return img.RGBAAt(x*cell+cell/2, y*cell+cell/2)
+	}
+	if c := center(1, 1); c != skyColor {
+		t.Errorf("empty cell is %v, want the sky %v", c, skyColor)
+	}
+	if c := center(m.Width()/2, m.Height()-1); c == skyColor {
+		t.Errorf("bedrock cell is sky coloured")
+	}
+
+	// the markers are outlines, the corner of their cell is drawn over the object
+	for _, marker := range []struct {
+		typ   levelmap.ObjectType
+		color color.RGBA
+	}{
+		{levelmap.ObjectPlayer, spawnColor},
+		{levelmap.ObjectGoal, goalColor},
+		{levelmap.ObjectNpc, npcColor},
+	} {
+		objects := m.ObjectsOf(marker.typ)
+		if len(objects) != 1 {
+			t.Fatalf("level 1 has %d %s objects, want 1", len(objects), marker.typ)
+		}
+		o := objects[0]
+		if c := img.RGBAAt(o.X*cell, o.Y*cell); c != marker.color {
+			t.Errorf("%s at %d,%d is marked %v, want %v", marker.typ, o.X, o.Y, c, marker.color)
+		}
+		if c := center(o.X, o.Y); c == marker.color {
+			t.Errorf("%s at %d,%d is filled with its marker, want an outline", marker.typ, o.X, o.Y)
+		}
+	}
+}
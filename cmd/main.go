@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	_ "image/png"
+	"io/fs"
 
 	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/assets"
 	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/game"
@@ -36,6 +38,30 @@ func realMain() error {
 		game.GroundFillDeeperT:     assets.GroundFillDeeper,
 		game.GroundFillDeeper2T:    assets.GroundFillDeeper2,
 		game.GroundFillDeeper3T:    assets.GroundFillDeeper3,
+		game.SpikesT:               assets.Spikes,
+		game.LavaT:                 assets.Lava,
+		game.WaterT:                assets.Water,
+		game.CoinT:                 assets.Coin,
+		game.GemT:                  assets.Gem,
+		game.PlatformT:             assets.Platform,
+		game.PlatformOneWayT:       assets.PlatformOneWay,
+		game.OneWayLeftT:           assets.OneWayLeft,
+		game.OneWayMidT:            assets.OneWayMid,
+		game.OneWayRightT:          assets.OneWayRight,
+		game.OneWaySingleT:         assets.OneWaySingle,
+		game.SlopeUpT:              assets.SlopeUp,
+		game.SlopeDownT:            assets.SlopeDown,
+		game.SlopeUpFillT:          assets.SlopeUpFill,
+		game.SlopeDownFillT:        assets.SlopeDownFill,
+		game.LadderT:               assets.Ladder,
+		game.KeyT:                  assets.Key,
+		game.DoorT:                 assets.Door,
+		game.DoorOpenT:             assets.DoorOpen,
+		game.SwitchT:               assets.Switch,
+		game.SwitchPressedT:        assets.SwitchPressed,
+		game.LeverOffT:             assets.LeverOff,
+		game.LeverOnT:              assets.LeverOn,
+		game.BlockT:                assets.Block,
 		game.BackgroundImageT:      assets.BackgroundImage,
 		game.BackgroundTownT:       assets.BackgroundTown,
 		game.BackgroundTownFrontT:  assets.BackgroundTownFront,
@@ -71,8 +97,25 @@ func realMain() error {
 		panic(err)
 	}
 
+	bindings, err := game.LoadBindings(cfg.Controls)
+	if errors.Is(err, fs.ErrNotExist) {
+		bindings = game.DefaultBindings()
+		err = bindings.Save(cfg.Controls)
+	}
+	if err != nil {
+		panic(err)
+	}
+
 	engine := game.NewEngine(cfg)
-	game.NewGame(engine, texture, font, music)
+	engine.SetBindings(bindings)
+	g := game.NewGame(engine, texture, font, music)
+	if cfg.Replay != "" {
+		replay, err := game.LoadReplay(cfg.Replay)
+		if err != nil {
+			panic(err)
+		}
+		g.PlayReplay(replay)
+	}
 
 	if err := engine.Start(); err != nil {
 		panic(err)
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/game"
+)
+
+func main() {
+	if err := realMain(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// realMain plays a replay file back without a window and prints how the run ended.
+func realMain() error {
+	extra := flag.Int("extra", 0, "ticks to keep simulating with no input after the replay ends")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		return fmt.Errorf("usage: replay [-extra ticks] file")
+	}
+
+	replay, err := game.LoadReplay(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	h, err := game.NewReplayHarness(replay)
+	if err != nil {
+		return err
+	}
+
+	ticks := len(replay.Ticks) + *extra
+	h.RunUntil(ticks, func(h *game.Harness) bool { return h.Won() || h.Lost() })
+
+	x, y := h.PlayerPosition()
+	fmt.Printf("level %d seed %d: %d/%d ticks, player at (%.2f, %.2f), grounded %v, won %v, lost %v\n",
+		replay.Level, replay.Seed, h.Tick(), len(replay.Ticks), x, y, h.Grounded(), h.Won(), h.Lost())
+	return nil
+}
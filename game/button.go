@@ -4,76 +4,65 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-type Relative float64
-
-type RelativeObject struct {
-	*RenderObject
-	x, y          Relative
-	offset, scale float64
-}
-
 type Button struct {
-	*RenderObject
-	x, y   Relative
+	*RectObject
 	scale  float64
+	input  *Input
 	action func()
 }
 
-func NewButton(tex *ebiten.Image, x, y Relative, scale float64, action func()) *Button {
+func NewButton(tex Drawable, area Rect, scale float64, input *Input, action func()) *Button {
 	b := &Button{
-		NewObject(tex),
-		x,
-		y,
-		scale,
-		action,
+		RectObject: NewRectObject(tex, area.Scale(scale)),
+		scale:      scale,
+		input:      input,
+		action:     action,
 	}
 
 	return b
 }
 
 func (b *Button) Layout(sw, sh float64) {
-	imgW, imgH := b.Texture.Size()
-	scale := sh / float64(imgH) * b.scale
-	b.Scale = Vec{
-		x: scale,
-		y: scale,
+	b.RectObject.Layout(sw, sh)
+
+	if b.Clicked() {
+		b.action()
+	}
+}
+
+func (b *Button) Clicked() bool {
+	if !b.input.JustPressed(ActionClick) {
+		return false
 	}
-	b.Position.x = sw * float64(b.x)
-	b.Position.y = sh * float64(b.y)
 
 	x, y := ebiten.CursorPosition()
+	return float64(x) > b.area.Left && float64(x) < b.area.Right && float64(y) > b.area.Top && float64(y) < b.area.Bottom
+}
 
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton0) {
-		if x > int(b.Position.x) && x < int(b.Position.x+float64(imgW)) && y > int(b.Position.y) && y < int(b.Position.y+float64(imgH)) {
-			b.action()
-		}
-	}
+func (b *Button) Activate() {
+	b.action()
 }
 
-func (b *Button) Draw(dst *Canvas) {
+func (b *Button) Nudge(dir float64) bool {
+	return false
+}
 
+func (b *Button) Draw(dst *Canvas) {
+	b.RectObject.Draw(dst)
 }
 
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 type ButtonOnOff struct {
 	*Button
-	x, y       Relative
-	onTex      *ebiten.Image
-	offTex     *ebiten.Image
-	state      bool
-	clickDelay int
+	onTex  Drawable
+	offTex Drawable
+	state  bool
 }
 
-func NewButtonOnOff(onTex, offTex *ebiten.Image, x, y Relative, scale float64, action func()) *ButtonOnOff {
+func NewButtonOnOff(onTex, offTex Drawable, area Rect, scale float64, input *Input, action func()) *ButtonOnOff {
 	b := &ButtonOnOff{
-		Button: &Button{
-			NewObject(onTex),
-			x,
-			y,
-			scale,
-			action,
-		},
+		Button: NewButton(onTex, area, scale, input, action),
 		onTex:  onTex,
 		offTex: offTex,
 		state:  true,
@@ -83,34 +72,23 @@ func NewButtonOnOff(onTex, offTex *ebiten.Image, x, y Relative, scale float64, a
 }
 
 func (b *ButtonOnOff) Layout(sw, sh float64) {
-	imgW, imgH := b.Texture.Size()
-	b.clickDelay++
-	if b.clickDelay > 60 {
-		b.clickDelay = 30
-	}
+	b.RectObject.Layout(sw, sh)
 
-	x, y := ebiten.CursorPosition()
-
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton0) {
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButton0) {
-			if x > int(b.Position.x) && x < int(b.Position.x+float64(imgW)) && y > int(b.Position.y) && y < int(b.Position.y+float64(imgH)) {
-				if b.state == true && b.clickDelay >= 30 {
-					b.Button.RenderObject.Texture = b.offTex
-					b.state = false
-					b.clickDelay = 0
-				}
-				if b.state == false && b.clickDelay >= 30 {
-					//do action
-					b.Button.RenderObject.Texture = b.onTex
-					b.state = true
-					b.clickDelay = 0
-				}
-				b.action()
-			}
-		}
+	if b.Clicked() {
+		b.Toggle()
 	}
 }
 
-func (b *ButtonOnOff) Draw(dst *Canvas) {
+func (b *ButtonOnOff) Activate() {
+	b.Toggle()
+}
 
+func (b *ButtonOnOff) Toggle() {
+	b.state = !b.state
+	if b.state {
+		b.texture = b.onTex
+	} else {
+		b.texture = b.offTex
+	}
+	b.action()
 }
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/assets"
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+// backgroundSets are the background layers a campaign level can name, drawn back to front.
+var backgroundSets = map[string][]Texture{
+	"town": {BackgroundImageT, BackgroundTownFrontT, BackgroundTownT},
+}
+
+// musicTracks are the tracks a campaign level can name, a level without music keeps the current track playing.
+var musicTracks = map[string]Audio{
+	"main": Music,
+}
+
+type CampaignLevel struct {
+	File       string `json:"file"`
+	Name       string `json:"name"`
+	Background string `json:"background"`
+	Music      string `json:"music"`
+}
+
+// Campaign is the ordered list of levels the game plays through. Levels are numbered from 1 in the order of the
+// manifest, the number is what saves, scores and replays refer to.
+type Campaign struct {
+	Name   string          `json:"name"`
+	Levels []CampaignLevel `json:"levels"`
+	files  fs.FS
+}
+
+// ParseCampaign reads a campaign manifest, the level files it names are looked up in files.
+func ParseCampaign(data []byte, files fs.FS) (*Campaign, error) {
+	c := &Campaign{files: files}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cant parse campaign %w", err)
+	}
+	if len(c.Levels) == 0 {
+		return nil, fmt.Errorf("campaign %q has no levels", c.Name)
+	}
+	for i, level := range c.Levels {
+		if _, err := fs.Stat(files, level.File); err != nil {
+			return nil, fmt.Errorf("campaign level %d has no file %w", i+1, err)
+		}
+		if _, ok := backgroundSets[level.Background]; !ok {
+			return nil, fmt.Errorf("campaign level %d has unknown background %q", i+1, level.Background)
+		}
+		if _, ok := musicTracks[level.Music]; !ok && level.Music != "" {
+			return nil, fmt.Errorf("campaign level %d has unknown music %q", i+1, level.Music)
+		}
+	}
+	return c, nil
+}
+
+// DefaultCampaign is the campaign embedded in the assets.
+func DefaultCampaign() (*Campaign, error) {
+	return ParseCampaign(assets.Campaign, assets.Levels)
+}
+
+func (c *Campaign) Level(lvl int) (CampaignLevel, error) {
+	if lvl < 1 || lvl > len(c.Levels) {
+		return CampaignLevel{}, fmt.Errorf("level %d does not exist", lvl)
+	}
+	return c.Levels[lvl-1], nil
+}
+
+// LevelMap reads the file of a level, in any format levelmap.Load reads.
+func (c *Campaign) LevelMap(lvl int) (*levelmap.Map, error) {
+	level, err := c.Level(lvl)
+	if err != nil {
+		return nil, err
+	}
+	tiles, err := levelmap.Load(c.files, level.File)
+	if err != nil {
+		return nil, fmt.Errorf("cant load level %d %w", lvl, err)
+	}
+	return tiles, nil
+}
+
+// Next returns the level played after lvl, false when lvl is the last one.
+func (c *Campaign) Next(lvl int) (int, bool) {
+	if lvl >= len(c.Levels) {
+		return 0, false
+	}
+	return lvl + 1, true
+}
+
+func (l CampaignLevel) Backgrounds() []Texture {
+	return backgroundSets[l.Background]
+}
+
+// Track returns the music of the level, false when it has none.
+func (l CampaignLevel) Track() (Audio, bool) {
+	track, ok := musicTracks[l.Music]
+	return track, ok
+}
@@ -0,0 +1,59 @@
+package game
+
+// cheerTime is how long the player plays the JoyFul animation after reaching a checkpoint.
+const cheerTime = 1.0
+
+// Checkpoint moves the player's respawn point onto itself the first time the player touches it. Only the last
+// touched checkpoint of a level is active.
+type Checkpoint struct {
+	*RectObject
+	inactiveTex Drawable
+	activeTex   Drawable
+	player      *Player
+	active      bool
+	onActivate  func(c *Checkpoint)
+}
+
+func NewCheckpoint(inactiveTex, activeTex Drawable, area Rect, player *Player) *Checkpoint {
+	return &Checkpoint{
+		RectObject:  NewRectObject(inactiveTex, area),
+		inactiveTex: inactiveTex,
+		activeTex:   activeTex,
+		player:      player,
+	}
+}
+
+func (c *Checkpoint) Step(dt float64) {
+	c.RectObject.Step(dt)
+	if c.active || c.player.Dead() {
+		return
+	}
+
+	if c.player.Hitbox().Overlaps(c.area) {
+		c.Activate()
+		c.player.Cheer()
+	}
+}
+
+// Activate makes the checkpoint the player's respawn point without the player touching it, the save system uses it
+// to put the player back at the saved checkpoint.
+func (c *Checkpoint) Activate() {
+	c.SetActive(true)
+	c.player.SetRespawn(c.area.Left, c.area.Bottom)
+	if c.onActivate != nil {
+		c.onActivate(c)
+	}
+}
+
+func (c *Checkpoint) SetActive(active bool) {
+	c.active = active
+	if active {
+		c.texture = c.activeTex
+	} else {
+		c.texture = c.inactiveTex
+	}
+}
+
+func (c *Checkpoint) Active() bool {
+	return c.active
+}
@@ -0,0 +1,82 @@
+package game
+
+type CollectibleKind int
+
+const (
+	CollectibleCoin CollectibleKind = iota
+	CollectibleGem
+)
+
+// Points is what picking the collectible up adds to the level score.
+func (k CollectibleKind) Points() int {
+	switch k {
+	case CollectibleGem:
+		return 5
+	}
+	return 1
+}
+
+// Collectible disappears and counts towards the level score once the player touches it.
+type Collectible struct {
+	*RectObject
+	kind      CollectibleKind
+	player    *Player
+	collected bool
+	onCollect func(c *Collectible)
+}
+
+func NewCollectible(kind CollectibleKind, tex Drawable, area Rect, player *Player) *Collectible {
+	return &Collectible{
+		RectObject: NewRectObject(tex, area),
+		kind:       kind,
+		player:     player,
+	}
+}
+
+func (c *Collectible) Draw(dst *Canvas) {
+	if c.collected {
+		return
+	}
+	c.RectObject.Draw(dst)
+}
+
+func (c *Collectible) Step(dt float64) {
+	if c.collected {
+		return
+	}
+	c.RectObject.Step(dt)
+
+	if c.player.Dead() || !c.player.Hitbox().Overlaps(c.area) {
+		return
+	}
+	c.collected = true
+	if c.onCollect != nil {
+		c.onCollect(c)
+	}
+}
+
+func (c *Collectible) Kind() CollectibleKind {
+	return c.kind
+}
+
+func (c *Collectible) Collected() bool {
+	return c.collected
+}
+
+// =====================================================================================================================
+
+// Score is what the player picked up in a level out of what there was to pick up.
+type Score struct {
+	Collected int `json:"collected"`
+	Total     int `json:"total"`
+	Points    int `json:"points"`
+	MaxPoints int `json:"maxPoints"`
+}
+
+// Better reports whether s beats other, more points win and on a tie the one with more pickups.
+func (s Score) Better(other Score) bool {
+	if s.Points != other.Points {
+		return s.Points > other.Points
+	}
+	return s.Collected > other.Collected
+}
@@ -0,0 +1,180 @@
+package game
+
+// Contact is a set of sides a box touched while being moved.
+type Contact int
+
+const (
+	ContactFloor Contact = 1 << iota
+	ContactCeiling
+	ContactLeftWall
+	ContactRightWall
+)
+
+// touchEpsilon is how close two edges have to be to count as touching.
+const touchEpsilon = 0.01
+
+func (c Contact) Has(other Contact) bool {
+	return c&other != 0
+}
+
+// MoveAndCollide moves box by dx first and then by dy, stopping each axis at the first solid ground in the way.
+// Grounds the box already overlaps are ignored on that axis so a box pushed into a tile can still walk out of it.
+// Besides the sides hit while moving, sides the box rests against afterwards are reported too, so a player
+// standing still on a tile still has a floor contact. One-way grounds only stop the box when it falls onto them.
+// Slopes are never walls, a box walking into one climbs its surface instead, and a box standing on a slope can
+// also step up onto a tile that is at most as high as the distance it moves sideways.
+func MoveAndCollide(box Rect, dx, dy float64, grounds []*Ground) (Rect, Contact) {
+	var contacts Contact
+
+	step := 0.0
+	if onSlope(box, grounds) {
+		step = abs(dx)
+	}
+
+	if dx > 0 {
+		for _, g := range grounds {
+			gBox := g.BoundingBox()
+			if !blocksSideways(g, box, step) || gBox.Left < box.Right-touchEpsilon {
+				continue
+			}
+			if dist := gBox.Left - box.Right; dist < dx {
+				dx = dist
+				contacts |= ContactRightWall
+			}
+		}
+	} else if dx < 0 {
+		for _, g := range grounds {
+			gBox := g.BoundingBox()
+			if !blocksSideways(g, box, step) || gBox.Right > box.Left+touchEpsilon {
+				continue
+			}
+			if dist := gBox.Right - box.Left; dist > dx {
+				dx = dist
+				contacts |= ContactLeftWall
+			}
+		}
+	}
+	box = box.Offset(dx, 0)
+
+	if rise := climb(box, grounds, abs(dx), step > 0); rise > 0 {
+		box = box.Offset(0, -rise)
+		contacts |= ContactFloor
+	}
+
+	if dy > 0 {
+		for _, g := range grounds {
+			if !g.Solid() || !overlapsHorizontally(box, g.BoundingBox()) {
+				continue
+			}
+			top := g.SurfaceAt(box)
+			if top < box.Bottom-touchEpsilon {
+				continue
+			}
+			if dist := top - box.Bottom; dist < dy {
+				dy = dist
+				contacts |= ContactFloor
+			}
+		}
+	} else if dy < 0 {
+		for _, g := range grounds {
+			gBox := g.BoundingBox()
+			if !g.Solid() || g.OneWay() || !overlapsHorizontally(box, gBox) || gBox.Bottom > box.Top+touchEpsilon {
+				continue
+			}
+			if dist := gBox.Bottom - box.Top; dist > dy {
+				dy = dist
+				contacts |= ContactCeiling
+			}
+		}
+	}
+	box = box.Offset(0, dy)
+
+	return box, contacts | Touching(box, grounds)
+}
+
+// blocksSideways reports whether g is a wall for box, step is how high a tile may be to be stepped onto instead.
+func blocksSideways(g *Ground, box Rect, step float64) bool {
+	if !g.Solid() || g.OneWay() || g.Sloped() {
+		return false
+	}
+	gBox := g.BoundingBox()
+	if !overlapsVertically(box, gBox) {
+		return false
+	}
+	return step <= 0 || gBox.Top < box.Bottom-step-touchEpsilon
+}
+
+// climb returns how far box has to be lifted to stand on the surface it walked into, at most maxRise. Flat tiles
+// are only climbed when stepping off a slope.
+func climb(box Rect, grounds []*Ground, maxRise float64, flat bool) float64 {
+	rise := 0.0
+	for _, g := range grounds {
+		if !g.Solid() || g.OneWay() || !g.Sloped() && !flat || !overlapsHorizontally(box, g.BoundingBox()) {
+			continue
+		}
+		if d := box.Bottom - g.SurfaceAt(box); d > rise && d <= maxRise+touchEpsilon {
+			rise = d
+		}
+	}
+	return rise
+}
+
+// onSlope reports whether box rests on the surface of a slope.
+func onSlope(box Rect, grounds []*Ground) bool {
+	for _, g := range grounds {
+		if g.Sloped() && overlapsHorizontally(box, g.BoundingBox()) && abs(g.SurfaceAt(box)-box.Bottom) <= touchEpsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// Touching reports the sides of box that lie against a solid ground, one-way grounds only count as floor and
+// slopes only count as floor or ceiling.
+func Touching(box Rect, grounds []*Ground) Contact {
+	var contacts Contact
+	for _, g := range grounds {
+		if !g.Solid() {
+			continue
+		}
+		gBox := g.BoundingBox()
+		if overlapsHorizontally(box, gBox) && abs(g.SurfaceAt(box)-box.Bottom) <= touchEpsilon {
+			contacts |= ContactFloor
+		}
+		if g.OneWay() {
+			continue
+		}
+		if overlapsHorizontally(box, gBox) && abs(gBox.Bottom-box.Top) <= touchEpsilon {
+			contacts |= ContactCeiling
+		}
+		if g.Sloped() {
+			continue
+		}
+		if overlapsVertically(box, gBox) {
+			if abs(gBox.Right-box.Left) <= touchEpsilon {
+				contacts |= ContactLeftWall
+			}
+			if abs(gBox.Left-box.Right) <= touchEpsilon {
+				contacts |= ContactRightWall
+			}
+		}
+	}
+	return contacts
+}
+
+// overlapsHorizontally and overlapsVertically ignore overlaps thinner than touchEpsilon, otherwise rounding
+// would let a box standing on a row of tiles snag on the seam between two of them.
+func overlapsHorizontally(a, b Rect) bool {
+	return a.Left < b.Right-touchEpsilon && a.Right > b.Left+touchEpsilon
+}
+
+func overlapsVertically(a, b Rect) bool {
+	return a.Top < b.Bottom-touchEpsilon && a.Bottom > b.Top+touchEpsilon
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestMoveAndCollide(t *testing.T) {
+	ground := func(area Rect) *Ground {
+		return NewGround(nil, area, true, 1)
+	}
+	oneWay := func(area Rect) *Ground {
+		g := ground(area)
+		g.SetOneWay(true)
+		return g
+	}
+	// box is a player sized box standing on y 100
+	box := Rect{Left: 0, Top: 60, Right: 20, Bottom: 100}
+
+	tests := []struct {
+		name     string
+		box      Rect
+		dx, dy   float64
+		grounds  []*Ground
+		want     Rect
+		contacts Contact
+	}{
+		{
+			name:     "large fall onto a thin floor",
+			box:      box.Offset(0, -500),
+			dy:       1000,
+			grounds:  []*Ground{ground(Rect{Left: -50, Top: 100, Right: 50, Bottom: 110})},
+			want:     box,
+			contacts: ContactFloor,
+		},
+		{
+			name: "large step into a wall",
+			box:  box,
+			dx:   1000,
+			grounds: []*Ground{
+				ground(Rect{Left: -50, Top: 100, Right: 500, Bottom: 150}),
+				ground(Rect{Left: 100, Top: 0, Right: 150, Bottom: 100}),
+			},
+			want:     box.Offset(80, 0),
+			contacts: ContactFloor | ContactRightWall,
+		},
+		{
+			name: "standing still in a corner",
+			box:  box,
+			grounds: []*Ground{
+				ground(Rect{Left: -50, Top: 100, Right: 50, Bottom: 150}),
+				ground(Rect{Left: 20, Top: 0, Right: 70, Bottom: 100}),
+				ground(Rect{Left: -50, Top: 10, Right: 50, Bottom: 60}),
+			},
+			want:     box,
+			contacts: ContactFloor | ContactRightWall | ContactCeiling,
+		},
+		{
+			name:     "standing still in the air",
+			box:      box.Offset(0, -1),
+			grounds:  []*Ground{ground(Rect{Left: -50, Top: 100, Right: 50, Bottom: 150})},
+			want:     box.Offset(0, -1),
+			contacts: 0,
+		},
+		{
+			name: "walking across a seam",
+			box:  box.Offset(30, 0),
+			dx:   8,
+			grounds: []*Ground{
+				ground(Rect{Left: 0, Top: 100, Right: 50, Bottom: 150}),
+				ground(Rect{Left: 50, Top: 100, Right: 100, Bottom: 150}),
+			},
+			want:     box.Offset(38, 0),
+			contacts: ContactFloor,
+		},
+		{
+			name:     "jumping through a one-way ground",
+			box:      box.Offset(0, 100),
+			dy:       -80,
+			grounds:  []*Ground{oneWay(Rect{Left: -50, Top: 100, Right: 50, Bottom: 110})},
+			want:     box.Offset(0, 20),
+			contacts: 0,
+		},
+		{
+			name:     "jumping into a solid ground",
+			box:      box.Offset(0, 100),
+			dy:       -80,
+			grounds:  []*Ground{ground(Rect{Left: -50, Top: 100, Right: 50, Bottom: 110})},
+			want:     box.Offset(0, 50),
+			contacts: ContactCeiling,
+		},
+		{
+			name:     "walking into a one-way ground from the side",
+			box:      box.Offset(0, -20),
+			dx:       40,
+			grounds:  []*Ground{oneWay(Rect{Left: 30, Top: 60, Right: 80, Bottom: 70})},
+			want:     box.Offset(40, -20),
+			contacts: 0,
+		},
+		{
+			name:     "falling onto a one-way ground",
+			box:      box.Offset(0, -20),
+			dy:       50,
+			grounds:  []*Ground{oneWay(Rect{Left: -50, Top: 100, Right: 50, Bottom: 110})},
+			want:     box,
+			contacts: ContactFloor,
+		},
+	}
+	for _, test := range tests {
+		got, contacts := MoveAndCollide(test.box, test.dx, test.dy, test.grounds)
+		if got != test.want || contacts != test.contacts {
+			t.Errorf("%s: moved to %+v with contacts %b, want %+v with %b",
+				test.name, got, contacts, test.want, test.contacts)
+		}
+	}
+}
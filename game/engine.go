@@ -16,7 +16,7 @@ type Renderable interface {
 // =====================================================================================================================
 
 type Drawable interface {
-	Update()
+	Update(dt float64)
 	Draw(dst *Canvas)
 	Size() (x, y float64)
 	Pivot() Vec
@@ -30,6 +30,11 @@ type Config struct {
 	Stage      int
 	Scale      float64
 	Fullscreen bool
+	Controls   string
+	TPS        int
+	Replay     string
+	Record     string
+	PhysicsDir string
 }
 
 func (cfg *Config) Reset() {
@@ -38,6 +43,9 @@ func (cfg *Config) Reset() {
 	cfg.Scale = 0
 	cfg.Stage = 1
 	cfg.Fullscreen = true
+	cfg.Controls = "controls.json"
+	cfg.TPS = TickRate
+	cfg.PhysicsDir = "physics"
 }
 
 func (cfg *Config) Configure(flags *flag.FlagSet) {
@@ -46,43 +54,49 @@ func (cfg *Config) Configure(flags *flag.FlagSet) {
 	flags.IntVar(&cfg.Stage, "stage", 0, "default stage settings")
 	flags.Float64Var(&cfg.Scale, "scale", 0, "Default scale settings")
 	flags.BoolVar(&cfg.Fullscreen, "fullscreen", false, "default fullscreen settings")
+	flags.StringVar(&cfg.Controls, "controls", "controls.json", "key bindings file")
+	flags.StringVar(&cfg.Replay, "replay", "", "replay file to play back instead of reading input")
+	flags.StringVar(&cfg.Record, "record", "", "file the input of each played level is recorded to, run-level<N>.rpl for -record run.rpl")
+	flags.StringVar(&cfg.PhysicsDir, "physics", "physics", "directory with per level player physics profiles (level<N>.json)")
+	flags.IntVar(&cfg.TPS, "tps", TickRate, "ticks per second of the engine, the simulation always steps at 60")
 }
 
 // =====================================================================================================================
 
 type Engine struct {
-	renderables       []Renderable
-	renderablesStack  [][]Renderable
-	stageStack        []int
-	Cfg               Config
-	camera            *Camera
-	windowSize        Vec
-	changingStage     bool
-	newGame           bool
-	blockCountDown    int
-	playerLevel       int
-	stage             int
-	mainScreen        func()
-	levelSettings     func()
-	levelGameSettings func()
-	levelGameOver     func()
-	level             func(int, bool)
-	updateCam         func()
+	scenes      *SceneManager
+	input       *Input
+	controllers *Controllers
+	device      DeviceID
+	bindings    Bindings
+	Cfg         Config
+	windowSize  Vec
+	newGame     bool
+	blockTimer  float64
+	playerLevel int
 }
 
 func NewEngine(cfg Config) *Engine {
-	e := &Engine{Cfg: cfg, camera: NewCamera(), stage: 0, changingStage: true, blockCountDown: 10, playerLevel: 1, newGame: true}
+	e := &Engine{Cfg: cfg, scenes: NewSceneManager(), input: NewInput(), controllers: NewControllers(), bindings: DefaultBindings(), blockTimer: 0.15, playerLevel: 1, newGame: true}
 	return e
 }
 
 func (e *Engine) Update() error {
-	e.blockCountDown++
-	if err := e.StageManager(); err != nil {
-		return fmt.Errorf("cant load Stage manager %w", err)
+	dt := e.Delta()
+	e.blockTimer += dt
+	e.controllers.Update()
+	e.input.Update(e.bindings.Poll(e.controllers))
+	e.followDevice()
+	e.handleActions()
+
+	if err := e.scenes.Flush(); err != nil {
+		return fmt.Errorf("cant change scene %w", err)
 	}
 
-	for _, object := range e.renderables {
-		object.Layout(e.windowSize.x, e.windowSize.y)
+	if scene := e.scenes.Current(); scene != nil {
+		if err := scene.Update(dt, e.windowSize.x, e.windowSize.y); err != nil {
+			return fmt.Errorf("cant update scene %s %w", e.scenes.CurrentName(), err)
+		}
 	}
 
 	return nil
@@ -92,13 +106,8 @@ func (e *Engine) Update() error {
 func (e *Engine) Draw(screen *ebiten.Image) {
 
 	canvas := NewCanvas(screen)
-	sw, sh := canvas.Size()
-	canvas.SetTransformation(e.camera.Transformation(sw, sh))
-
-	if e.renderables != nil {
-		for _, object := range e.renderables {
-			object.Draw(canvas)
-		}
+	if scene := e.scenes.Current(); scene != nil {
+		scene.Draw(canvas)
 	}
 }
 
@@ -109,11 +118,6 @@ func (e *Engine) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHei
 	return int(e.windowSize.x), int(e.windowSize.y)
 }
 
-func (e *Engine) AddObject(obj Renderable) {
-	e.renderables = append(e.renderables, obj)
-
-}
-
 func (e *Engine) Scale() float64 {
 	scale := ebiten.DeviceScaleFactor()
 	if e.Cfg.Scale != 0 {
@@ -128,6 +132,9 @@ func (e *Engine) Start() error {
 	ebiten.SetWindowSize(e.Cfg.Width, e.Cfg.Height)
 	ebiten.SetFullscreen(e.Cfg.Fullscreen)
 	ebiten.SetWindowTitle("Alone In The World")
+	if e.Cfg.TPS > 0 {
+		ebiten.SetTPS(e.Cfg.TPS)
+	}
 	if err := ebiten.RunGame(e); err != nil {
 		return fmt.Errorf("cant start game %w", err)
 	}
@@ -135,149 +142,123 @@ func (e *Engine) Start() error {
 	return nil
 }
 
-func (e *Engine) StageManager() error {
+// Delta is the time covered by one Update call.
+func (e *Engine) Delta() float64 {
+	tps := ebiten.TPS()
+	if tps <= 0 {
+		return FixedDelta
+	}
+	return 1 / float64(tps)
+}
+
+func (e *Engine) Scenes() *SceneManager {
+	return e.scenes
+}
 
-	//dubugging tools
-	if ebiten.IsKeyPressed(ebiten.Key1) {
-		e.ChangeStage(4)
+func (e *Engine) Input() *Input {
+	return e.input
+}
+
+func (e *Engine) Controllers() *Controllers {
+	return e.controllers
+}
+
+func (e *Engine) SetBindings(bindings Bindings) {
+	e.bindings = bindings
+}
+
+// followDevice hides the mouse cursor while a gamepad is in use and shows it again once the keyboard or the mouse
+// is used, menus are then navigated by focus alone.
+func (e *Engine) followDevice() {
+	device := e.controllers.Active()
+	if device == e.device {
+		return
+	}
+	e.device = device
+	if _, gamepad := device.Gamepad(); gamepad {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
 	}
+}
 
-	if e.changingStage == true {
-		e.ClearCamera()
-		switch e.stage {
-		case 0:
-			if e.mainScreen != nil {
-				if len(e.renderablesStack) == 0 {
-					e.mainScreen()
-					e.PushStage(0, e.renderables)
-				}
-			}
-		case 1:
-			if e.level != nil {
-				if len(e.stageStack) <= 1 {
-					e.level(e.playerLevel, e.newGame)
-					e.PushStage(e.stage, e.renderables)
-				}
-			}
-			if e.updateCam != nil {
-				e.updateCam()
-			}
-		case 2:
-			if e.levelGameSettings != nil {
-				e.levelGameSettings()
-				e.PushStage(e.stage, e.renderables)
-			}
-		case 4:
-			if e.levelGameOver != nil {
-				e.levelGameOver()
-				e.PushStage(e.stage, e.renderables)
-			}
-		case 10:
-			if e.levelSettings != nil {
-				e.levelSettings()
-				e.PushStage(10, e.renderables)
-			}
-		}
-		e.changingStage = false
+func (e *Engine) handleActions() {
+	//dubugging tools
+	if e.input.JustPressed(ActionDebugGameOver) {
+		e.PushScene(SceneGameOver)
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		if e.stage == 10 {
-			e.ChangeStage(0)
-		} else if e.stage == 1 {
-			e.ChangeStage(2)
-		} else if e.stage == 2 {
-			e.ChangeStage(1)
-		} else {
-			e.ChangeStage(10)
+	// B backs out of menus but should not pause a running level
+	if e.input.JustPressed(ActionPause) || e.input.JustPressed(ActionBack) && e.scenes.CurrentName() != SceneLevel {
+		if back, ok := e.scenes.Current().(BackHandler); ok {
+			e.navigate(back.Back)
 		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.Key0) {
-		e.ChangeStage(-1)
+	if e.input.JustPressed(ActionQuit) {
+		e.Exit()
 	}
-	return nil
-}
-
-func (e *Engine) PushStage(stage int, renderables []Renderable) {
-	e.stageStack = append(e.stageStack, stage)
-	e.renderablesStack = append(e.renderablesStack, renderables)
 }
 
-func (e *Engine) PopStage() {
-	length := len(e.stageStack)
-	if length == 0 {
+// navigate debounces scene changes so a held key or mouse button does not fire through several scenes at once.
+func (e *Engine) navigate(change func()) {
+	if e.blockTimer <= 0.25 {
 		return
 	}
+	change()
+	e.blockTimer = 0
+}
 
-	e.stageStack = e.stageStack[:length-1]
-	if length == 2 {
-		e.stage = e.stageStack[0]
-	} else {
-		e.stage = e.stageStack[length-2]
-	}
+func (e *Engine) PushScene(name string) {
+	e.navigate(func() { e.scenes.Push(name) })
+}
 
-	e.ClearRenderables()
-	e.renderables = e.renderablesStack[length-1]
-	e.renderablesStack = e.renderablesStack[:length-1]
-	if length == 2 {
-		e.renderables = e.renderablesStack[0]
-	} else {
-		e.renderables = e.renderablesStack[length-2]
-	}
+func (e *Engine) PopScene() {
+	e.navigate(func() { e.scenes.Pop() })
 }
 
-func (e *Engine) ClearRenderables() {
-	e.renderables = nil
+func (e *Engine) ReplaceScene(name string) {
+	e.navigate(func() { e.scenes.Replace(name) })
 }
 
-func (e *Engine) ChangeStage(value int) {
-	popping := false
-	if e.blockCountDown > 15 {
-		if value == -1 {
-			os.Exit(0)
-		}
-		if value < e.stage {
-			e.PopStage()
-			popping = true
-		} else if value == e.stage {
-			e.PopStage()
-		}
-		if popping != true {
-			e.stage = value
-		}
-		e.changingStage = true
-		e.blockCountDown = 0
+func (e *Engine) ResetScene(name string) {
+	e.navigate(func() { e.scenes.Reset(name) })
+}
 
-	} else {
-		return
+func (e *Engine) Exit() {
+	if e.blockTimer > 0.25 {
+		e.scenes.Close()
+		os.Exit(0)
 	}
 }
 
 func (e *Engine) moveBackToStart() {
-	e.PopStage()
-	e.ChangeStage(0)
+	e.ResetScene(SceneMainMenu)
 	e.playerLevel = 1
 }
 
-func (e *Engine) ClearCamera() {
-	e.camera = NewCamera()
-}
-
 func (e *Engine) ChangePlayerLvL(val int) {
-
-	e.ChangeStage(1)
+	e.ReplaceScene(SceneLevel)
 	e.playerLevel = val
+	// the saved position and checkpoint belong to the level that was continued, the next one starts fresh
+	e.newGame = true
 }
 
 func (e *Engine) NewGameBool() {
 	e.newGame = true
-	e.ChangeStage(1)
+	e.startLevel()
 }
 
 func (e *Engine) SavedGameBool() {
 	e.newGame = false
-	e.ChangeStage(1)
+	e.startLevel()
+}
+
+func (e *Engine) startLevel() {
+	e.navigate(func() {
+		e.scenes.Reset(SceneMainMenu)
+		e.scenes.Push(SceneLevel)
+	})
 }
 
 func (e *Engine) ChangeFullscreen() {
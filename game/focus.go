@@ -0,0 +1,118 @@
+package game
+
+import (
+	"image/color"
+	"math"
+)
+
+type Focusable interface {
+	BoundingBox() Rect
+	Activate()
+	// Nudge is called with -1 or 1 when left or right is pressed, it reports false if the widget does not
+	// use horizontal input so focus can move instead.
+	Nudge(dir float64) bool
+}
+
+type FocusGroup struct {
+	input   *Input
+	widgets []Focusable
+	focused int
+}
+
+func NewFocusGroup(input *Input, widgets []Focusable) *FocusGroup {
+	f := &FocusGroup{
+		input:   input,
+		widgets: widgets,
+	}
+	f.focused = f.first()
+
+	return f
+}
+
+func (f *FocusGroup) Layout(sw, sh float64) {
+	if len(f.widgets) == 0 {
+		return
+	}
+
+	current := f.widgets[f.focused]
+	switch {
+	case f.input.JustPressed(ActionConfirm):
+		current.Activate()
+	case f.input.JustPressed(ActionMoveUp):
+		f.move(0, -1)
+	case f.input.JustPressed(ActionMoveDown):
+		f.move(0, 1)
+	case f.input.JustPressed(ActionMoveLeft):
+		if !current.Nudge(-1) {
+			f.move(-1, 0)
+		}
+	case f.input.JustPressed(ActionMoveRight):
+		if !current.Nudge(1) {
+			f.move(1, 0)
+		}
+	}
+}
+
+func (f *FocusGroup) Draw(dst *Canvas) {
+	if len(f.widgets) == 0 {
+		return
+	}
+
+	box := f.widgets[f.focused].BoundingBox().withPadding(-4, 4)
+	dst.DrawRect(box, color.RGBA{R: 255, G: 255, B: 255, A: 80})
+}
+
+func (f *FocusGroup) Focused() Focusable {
+	if len(f.widgets) == 0 {
+		return nil
+	}
+	return f.widgets[f.focused]
+}
+
+// first picks the top left widget so menus always open on the same button.
+func (f *FocusGroup) first() int {
+	best := 0
+	for i, w := range f.widgets {
+		box, bestBox := w.BoundingBox(), f.widgets[best].BoundingBox()
+		if box.Top < bestBox.Top || box.Top == bestBox.Top && box.Left < bestBox.Left {
+			best = i
+		}
+	}
+	return best
+}
+
+// move focuses the closest widget whose centre lies in the (dx, dy) direction. Distance along the other axis
+// counts double so moving down prefers the widget straight below over one diagonally closer.
+func (f *FocusGroup) move(dx, dy float64) {
+	from := center(f.widgets[f.focused].BoundingBox())
+
+	best := -1
+	bestScore := math.Inf(1)
+	for i, w := range f.widgets {
+		if i == f.focused {
+			continue
+		}
+		to := center(w.BoundingBox())
+		along := (to.x-from.x)*dx + (to.y-from.y)*dy
+		if along <= 0 {
+			continue
+		}
+		across := math.Abs((to.x-from.x)*dy) + math.Abs((to.y-from.y)*dx)
+		score := along + across*2
+		if score < bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+
+	if best >= 0 {
+		f.focused = best
+	}
+}
+
+func center(r Rect) Vec {
+	return Vec{
+		x: r.Left + r.width()/2,
+		y: r.Top + r.height()/2,
+	}
+}
@@ -2,11 +2,25 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
-	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/assets"
+const (
+	SceneMainMenu = "main-menu"
+	SceneSettings = "settings"
+	SceneLevel    = "level"
+	ScenePause    = "pause"
+	SceneGameOver = "game-over"
+	// SceneCampaignComplete follows the goal of the last level of the campaign
+	SceneCampaignComplete = "campaign-complete"
 )
 
 type Game struct {
@@ -14,106 +28,211 @@ type Game struct {
 	texture    *TextureManager
 	font       *FontManager
 	music      *AudioManager
+	campaign   *Campaign
+	track      Audio
 	playerArea Rect
 	player     *Player
+	level      *Level
+	checkpoint int
+	mechanisms MechanismState
+	scores     map[int]Score
+	replay     *Replay
 	dataLoaded bool
 }
 
 func NewGame(e *Engine, tex *TextureManager, font *FontManager, music *AudioManager) *Game {
+	campaign, err := DefaultCampaign()
+	if err != nil {
+		panic(fmt.Errorf("illegall state, embeded campaign is invalid %w", err))
+	}
 	g := &Game{
 		engine:     e,
 		texture:    tex,
 		font:       font,
 		music:      music,
+		campaign:   campaign,
+		scores:     map[int]Score{},
 		dataLoaded: false,
 	}
-
-	e.mainScreen = func() {
-		g.LoadStartMenu()
+	if _, err := os.Stat("playerScores.json"); err == nil {
+		loadFromJSON("playerScores.json", &g.scores)
 	}
 
-	e.level = func(lvl int, newGame bool) {
-		g.LoadLevel(lvl, newGame)
-	}
+	scenes := e.Scenes()
+	scenes.Register(SceneMainMenu, func() Scene {
+		return NewRenderableScene(g.LoadStartMenu, func() { e.scenes.Push(SceneSettings) })
+	})
+	scenes.Register(SceneSettings, func() Scene {
+		return NewRenderableScene(g.LoadSettings, func() { e.scenes.Pop() })
+	})
+	scenes.Register(SceneLevel, func() Scene {
+		return NewRenderableScene(func(s *RenderableScene) { g.LoadLevel(s, e.playerLevel, e.newGame) }, func() { e.scenes.Push(ScenePause) })
+	})
+	scenes.Register(ScenePause, func() Scene {
+		return NewRenderableScene(g.LoadGameSettings, func() { e.scenes.Pop() })
+	})
+	scenes.Register(SceneGameOver, func() Scene {
+		return NewRenderableScene(g.LoadGameOver, func() { e.scenes.Reset(SceneMainMenu) })
+	})
+	scenes.Register(SceneCampaignComplete, func() Scene {
+		return NewRenderableScene(g.LoadCampaignComplete, func() { e.scenes.Reset(SceneMainMenu) })
+	})
+	scenes.Push(SceneMainMenu)
 
-	e.levelSettings = func() {
-		g.LoadSettings()
-	}
+	//g.music.LoadAudio(Music).Play()
 
-	e.levelGameSettings = func() {
-		g.LoadGameSettings()
+	return g
+}
+
+func (g *Game) LoadLevel(s *RenderableScene, lvl int, newGame bool) {
+	info, err := g.campaign.Level(lvl)
+	if err != nil {
+		panic(fmt.Errorf("illegall state, cant load level %w", err))
+	}
+	tiles, err := g.campaign.LevelMap(lvl)
+	if err != nil {
+		panic(fmt.Errorf("illegall state, embeded level %d is invalid %w", lvl, err))
+	}
+	level := NewLevel(tiles)
+
+	// a replay drives only the level it was recorded on, whatever is loaded after it is played
+	replay := g.replay
+	g.replay = nil
+
+	completed := false
+	env := LevelEnv{
+		Assets:      g.texture,
+		Scale:       g.engine.Scale(),
+		Seed:        time.Now().UnixNano(),
+		Backgrounds: info.Backgrounds(),
+		Input:       g.engine.Input().Current,
+		OnWin: func() {
+			if replay != nil {
+				g.engine.ResetScene(SceneMainMenu)
+				return
+			}
+			if !completed {
+				completed = true
+				g.recordScore(lvl, level.Score())
+			}
+			g.completeLevel(lvl)
+		},
+		OnLose: func() { g.engine.ReplaceScene(SceneGameOver) },
+	}
+	physics, err := LoadPlayerPhysics(filepath.Join(g.engine.Cfg.PhysicsDir, fmt.Sprintf("level%d.json", lvl)))
+	if err == nil {
+		env.Physics = &physics
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("using default player physics %v", err)
+	}
+	if replay != nil {
+		env.Scale = replay.Scale
+		env.Seed = replay.Seed
+		env.Physics = replay.Physics
+		env.Input = func() ActionSet { return replay.At(level.World().Ticks()) }
+	}
+	for _, renderable := range level.Build(env) {
+		s.AddObject(renderable)
 	}
 
-	e.levelGameOver = func() {
-		g.LoadGameOver()
+	s.AddObject(NewHud(g.font.LoadFont(TusjF), 18*g.engine.Scale(), info.Name, level))
+	if track, ok := info.Track(); ok {
+		g.playMusic(track)
 	}
 
-	e.updateCam = func() {
-		g.UpdateCamera()
+	g.player = level.player
+	g.level = level
+	s.SetWorld(level.World())
+	s.Camera().UpdateMainCharacter(g.player)
+
+	// continuing resumes from the saved checkpoint, older saves only have the player position
+	var saved *SavedRun
+	if replay != nil {
+		saved = replay.Continued
+	} else if !newGame {
+		saved = &SavedRun{Mechanisms: g.mechanisms, Checkpoint: g.checkpoint, Left: g.playerArea.Left, Top: g.playerArea.Top}
 	}
 
-	//g.music.LoadAudio(Music).Play()
+	if replay == nil && g.engine.Cfg.Record != "" {
+		recording := NewReplay(lvl, env.Scale, env.Seed)
+		recording.Physics = env.Physics
+		recording.Continued = saved
+		level.World().SetRecorder(recording)
+		s.OnExit(func() {
+			if err := recording.Save(RecordingPath(g.engine.Cfg.Record, lvl)); err != nil {
+				log.Printf("cant save recording %v", err)
+			}
+		})
+	}
 
-	return g
+	if saved != nil {
+		if err := level.Continue(*saved); err != nil {
+			log.Printf("cant fully continue the saved run %v", err)
+		}
+	}
 }
 
-func (g *Game) LoadLevel(lvl int, newGame bool) {
-	var level *Level
-	var err error
-	switch lvl {
-	case 1:
-		level, err = ParseLevel(assets.Level1)
-	case 2:
-		level, err = ParseLevel(assets.Level2)
+// completeLevel moves on to the next level of the campaign, or to the campaign complete stage after the last one.
+func (g *Game) completeLevel(lvl int) {
+	if next, ok := g.campaign.Next(lvl); ok {
+		g.engine.ChangePlayerLvL(next)
+		return
 	}
+	g.engine.ReplaceScene(SceneCampaignComplete)
+}
 
-	if err != nil {
-		panic(fmt.Errorf("illegall state, embeded level 1 is invalid %w", err))
+// playMusic switches to the given track, a track that is already playing keeps playing.
+func (g *Game) playMusic(track Audio) {
+	if track == g.track {
+		return
 	}
-	for _, renderable := range level.Build(g) {
-		g.engine.AddObject(renderable)
+	if g.track != 0 {
+		g.music.LoadAudio(g.track).Pause()
 	}
+	g.track = track
+	g.music.Play(track)
+}
 
-	g.player = level.player
-	g.UpdateCamera()
-
-	if !newGame {
-		deltaX := g.playerArea.Left - level.player.area.Left
-		deltaY := g.playerArea.Top - level.player.area.Top
-		level.player.area = level.player.area.Offset(deltaX, deltaY)
-	}
+// PlayReplay skips the main menu and runs the replayed level with the recorded input, winning it returns to the
+// main menu.
+func (g *Game) PlayReplay(r *Replay) {
+	g.replay = r
+	g.engine.playerLevel = r.Level
+	g.engine.newGame = true
+	g.engine.scenes.Push(SceneLevel)
 }
 
-func (g *Game) LoadStartMenu() {
+func (g *Game) LoadStartMenu(s *RenderableScene) {
 
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
 
 	//new game
 	cell := Rect{250, 250, 350, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), func() { g.engine.NewGameBool() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.NewGameBool() }))
 
 	//continue
 	cell = Rect{400, 250, 500, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonContinueT)), cell, g.engine.Scale(), func() { g.load() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonContinueT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.load() }))
 
 	//settings
 	cell = Rect{550, 250, 650, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSettingsT)), cell, g.engine.Scale(), func() { g.engine.ChangeStage(10) }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSettingsT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.PushScene(SceneSettings) }))
 
 	//exit
 	cell = Rect{700, 250, 800, 300}
-	g.engine.AddObject(
+	s.AddObject(
 		NewButton(
 			NewDrawableTexture(g.texture.LoadTexture(ButtonExitT)),
 			cell,
 			g.engine.Scale(),
-			func() { g.engine.ChangeStage(-1) },
+			g.engine.Input(),
+			func() { g.engine.Exit() },
 		),
 	)
 
-	g.engine.AddObject(
+	s.AddObject(
 		NewText(
 			g.font.LoadFont(TusjF),
 			"Hello",
@@ -122,66 +241,72 @@ func (g *Game) LoadStartMenu() {
 			0.3,
 		),
 	)
+
+	s.AddObject(NewFocusGroup(g.engine.Input(), s.Focusables()))
 }
 
-func (g *Game) LoadSettings() {
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
+func (g *Game) LoadSettings(s *RenderableScene) {
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
 
 	//back to start
 	cell := Rect{250, 250, 350, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), func() { g.engine.ChangeStage(0) }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.PopScene() }))
 
 	//to do
 	cell = Rect{250, 350, 350, 400}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSettingsT)), cell, g.engine.Scale(), func() { g.engine.ChangeStage(0) }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSettingsT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.PopScene() }))
 
 	//exit
 	cell = Rect{250, 450, 350, 500}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonExitT)), cell, g.engine.Scale(), func() { g.engine.ChangeStage(-1) }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonExitT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.Exit() }))
 
 	//fullscreen
 	cell = Rect{250, 550, 350, 600}
-	g.engine.AddObject(NewButtonOnOff(NewDrawableTexture(g.texture.LoadTexture(ButtonOnT)), NewDrawableTexture(g.texture.LoadTexture(ButtonOffT)), cell, g.engine.Scale(), func() { g.engine.ChangeFullscreen() }))
+	s.AddObject(NewButtonOnOff(NewDrawableTexture(g.texture.LoadTexture(ButtonOnT)), NewDrawableTexture(g.texture.LoadTexture(ButtonOffT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.ChangeFullscreen() }))
 
 	//volume
 	cell = Rect{200, 650, 400, 675}
-	g.engine.AddObject(NewSlider(NewDrawableTexture(g.texture.LoadTexture(ButtonNoTextT)), NewDrawableTexture(g.texture.LoadTexture(ButtonSliderT)), cell, g.engine.Scale(), g.music.ChangeVolume))
+	s.AddObject(NewSlider(NewDrawableTexture(g.texture.LoadTexture(ButtonNoTextT)), NewDrawableTexture(g.texture.LoadTexture(ButtonSliderT)), cell, g.engine.Scale(), g.engine.Input(), g.music.ChangeVolume))
+
+	s.AddObject(NewFocusGroup(g.engine.Input(), s.Focusables()))
 }
 
-func (g *Game) LoadGameSettings() {
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
+func (g *Game) LoadGameSettings(s *RenderableScene) {
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
 
 	//back to start
 	cell := Rect{250, 250, 350, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), func() { g.engine.moveBackToStart() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.moveBackToStart() }))
 
 	//Save
 	cell = Rect{250, 350, 350, 400}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSaveT)), cell, g.engine.Scale(), func() { g.save() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonSaveT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.save() }))
 
 	//fullscreen
 	cell = Rect{250, 550, 350, 600}
-	g.engine.AddObject(NewButtonOnOff(NewDrawableTexture(g.texture.LoadTexture(ButtonOnT)), NewDrawableTexture(g.texture.LoadTexture(ButtonOffT)), cell, g.engine.Scale(), func() {}))
+	s.AddObject(NewButtonOnOff(NewDrawableTexture(g.texture.LoadTexture(ButtonOnT)), NewDrawableTexture(g.texture.LoadTexture(ButtonOffT)), cell, g.engine.Scale(), g.engine.Input(), func() {}))
 
 	//volume
 	cell = Rect{200, 650, 400, 675}
-	g.engine.AddObject(NewSlider(NewDrawableTexture(g.texture.LoadTexture(ButtonNoTextT)), NewDrawableTexture(g.texture.LoadTexture(ButtonSliderT)), cell, g.engine.Scale(), g.music.ChangeVolume))
+	s.AddObject(NewSlider(NewDrawableTexture(g.texture.LoadTexture(ButtonNoTextT)), NewDrawableTexture(g.texture.LoadTexture(ButtonSliderT)), cell, g.engine.Scale(), g.engine.Input(), g.music.ChangeVolume))
 
 	//exit
 	cell = Rect{250, 450, 350, 500}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonExitT)), cell, g.engine.Scale(), func() { g.engine.ChangeStage(-1) }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonExitT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.Exit() }))
+
+	s.AddObject(NewFocusGroup(g.engine.Input(), s.Focusables()))
 }
 
-func (g *Game) LoadGameOver() {
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
-	g.engine.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
+func (g *Game) LoadGameOver(s *RenderableScene) {
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
 
-	g.engine.AddObject(
+	s.AddObject(
 		NewText(
 			g.font.LoadFont(TusjF),
 			"Game over!",
@@ -193,23 +318,83 @@ func (g *Game) LoadGameOver() {
 
 	//back to start
 	cell := Rect{250, 250, 350, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), func() { g.engine.moveBackToStart() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.moveBackToStart() }))
 
 	//continue
 	cell = Rect{400, 250, 500, 300}
-	g.engine.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonContinueT)), cell, g.engine.Scale(), func() { g.load() }))
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonContinueT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.load() }))
+
+	s.AddObject(NewFocusGroup(g.engine.Input(), s.Focusables()))
+}
+
+func (g *Game) LoadCampaignComplete(s *RenderableScene) {
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
+	s.AddObject(NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
+
+	lines := []string{fmt.Sprintf("%s complete!", g.campaign.Name)}
+	for i, level := range g.campaign.Levels {
+		if score, ok := g.BestScore(i + 1); ok {
+			lines = append(lines, fmt.Sprintf("%s  %d/%d", level.Name, score.Points, score.MaxPoints))
+		}
+	}
+	s.AddObject(
+		NewText(
+			g.font.LoadFont(TusjF),
+			strings.Join(lines, "\n"),
+			24*g.engine.Scale(),
+			0.2,
+			0.2,
+		),
+	)
+
+	//back to start
+	cell := Rect{250, 250, 350, 300}
+	s.AddObject(NewButton(NewDrawableTexture(g.texture.LoadTexture(ButtonStartT)), cell, g.engine.Scale(), g.engine.Input(), func() { g.engine.moveBackToStart() }))
+
+	s.AddObject(NewFocusGroup(g.engine.Input(), s.Focusables()))
 }
 
 func (g *Game) save() {
 	saveToJSON("playerData.json", g.player.area)
 	saveToJSON("playerLevel.json", g.engine.playerLevel)
+	saveToJSON("playerCheckpoint.json", g.level.ActiveCheckpoint())
+	saveToJSON("playerMechanisms.json", g.level.Mechanisms())
+}
+
+// recordScore keeps the best score of every completed level, it is saved right away so a finished level counts
+// even when the game is never saved.
+func (g *Game) recordScore(lvl int, score Score) {
+	if best, ok := g.scores[lvl]; ok && !score.Better(best) {
+		return
+	}
+	g.scores[lvl] = score
+	saveToJSON("playerScores.json", g.scores)
+}
+
+func (g *Game) BestScore(lvl int) (Score, bool) {
+	score, ok := g.scores[lvl]
+	return score, ok
 }
 
 func (g *Game) load() {
 	var levelData int
 	loadFromJSON("playerData.json", &g.playerArea)
 	loadFromJSON("playerLevel.json", &levelData)
+	// saves from before checkpoints have no checkpoint file
+	g.checkpoint = -1
+	if _, err := os.Stat("playerCheckpoint.json"); err == nil {
+		loadFromJSON("playerCheckpoint.json", &g.checkpoint)
+	}
+	g.mechanisms = MechanismState{}
+	if _, err := os.Stat("playerMechanisms.json"); err == nil {
+		loadFromJSON("playerMechanisms.json", &g.mechanisms)
+	}
 
+	if _, err := g.campaign.Level(levelData); err != nil {
+		log.Printf("saved level is not in the campaign, starting over %v", err)
+		levelData = 1
+	}
 	g.engine.playerLevel = levelData
 
 	g.engine.SavedGameBool()
@@ -229,7 +414,3 @@ func loadFromJSON(filename string, result interface{}) {
 	}
 	json.Unmarshal([]byte(file), result)
 }
-
-func (g *Game) UpdateCamera() {
-	g.engine.camera.UpdateMainCharacter(g.player)
-}
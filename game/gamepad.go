@@ -0,0 +1,87 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DeviceID is either KeyboardDevice or the id of a gamepad shifted by one.
+type DeviceID int
+
+const KeyboardDevice DeviceID = 0
+
+func GamepadDevice(id ebiten.GamepadID) DeviceID {
+	return DeviceID(id) + 1
+}
+
+func (d DeviceID) Gamepad() (ebiten.GamepadID, bool) {
+	if d == KeyboardDevice {
+		return 0, false
+	}
+	return ebiten.GamepadID(d - 1), true
+}
+
+// =====================================================================================================================
+
+type Controllers struct {
+	gamepads []ebiten.GamepadID
+	active   DeviceID
+}
+
+func NewControllers() *Controllers {
+	return &Controllers{active: KeyboardDevice}
+}
+
+// Update refreshes the list of connected gamepads, it runs every tick so controllers can be plugged in and out
+// mid-game. Gamepads without the standard layout are skipped because the bindings are expressed in it.
+func (c *Controllers) Update() {
+	ids := ebiten.AppendGamepadIDs(nil)
+	c.gamepads = c.gamepads[:0]
+	for _, id := range ids {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			c.gamepads = append(c.gamepads, id)
+		}
+	}
+
+	if id, ok := c.active.Gamepad(); ok && !c.connected(id) {
+		c.active = KeyboardDevice
+	}
+}
+
+func (c *Controllers) Gamepads() []ebiten.GamepadID {
+	return c.gamepads
+}
+
+func (c *Controllers) Active() DeviceID {
+	return c.active
+}
+
+func (c *Controllers) connected(id ebiten.GamepadID) bool {
+	for _, gamepad := range c.gamepads {
+		if gamepad == id {
+			return true
+		}
+	}
+	return false
+}
+
+// use switches the active device when the current one stayed idle while another one was used.
+func (c *Controllers) use(keyboard bool, gamepads map[ebiten.GamepadID]bool) {
+	if id, ok := c.active.Gamepad(); ok {
+		if gamepads[id] {
+			return
+		}
+	} else if keyboard {
+		return
+	}
+
+	if keyboard {
+		c.active = KeyboardDevice
+		return
+	}
+	for _, id := range c.gamepads {
+		if gamepads[id] {
+			c.active = GamepadDevice(id)
+			return
+		}
+	}
+}
@@ -5,22 +5,20 @@ type BoundingBoxer interface {
 }
 
 type Goal struct {
-	player       *Player
-	lostText     *Text
-	winText      *Text
-	winZone      BoundingBoxer
-	scale        float64
-	onWinAction  func()
-	onLoseAction func()
+	player      *Player
+	lostText    *Text
+	winText     *Text
+	winZone     BoundingBoxer
+	killBounds  Rect
+	onWinAction func()
 }
 
-func NewGoal(player *Player, winZone BoundingBoxer, scale float64, onwin func(), onLose func()) *Goal {
+func NewGoal(player *Player, winZone BoundingBoxer, killBounds Rect, onwin func()) *Goal {
 	return &Goal{
-		player:       player,
-		winZone:      winZone,
-		scale:        scale,
-		onWinAction:  onwin,
-		onLoseAction: onLose,
+		player:      player,
+		winZone:     winZone,
+		killBounds:  killBounds,
+		onWinAction: onwin,
 	}
 }
 
@@ -28,13 +26,19 @@ func (g *Goal) Draw(canvas *Canvas) {
 }
 
 func (g *Goal) Layout(sw, sh float64) {
-	if g.player.area.Bottom > 1000*g.scale {
-		g.onLoseAction()
+}
+
+// Step ends the level when the player reaches the win zone and costs a life when it leaves the kill bounds. A
+// player out of lives has already lost, touching the win zone afterwards does not win the level too.
+func (g *Goal) Step(dt float64) {
+	if g.player.Dead() {
+		return
 	}
+	playerBox := g.player.Hitbox()
 
-	playerBox := g.player.BoundingBox()
-	if g.player.lookingDirR {
-		playerBox = playerBox.ChangeX(0)
+	if !playerBox.Overlaps(g.killBounds) {
+		g.player.Kill(DamageFall)
+		return
 	}
 
 	if playerBox.Overlaps(g.winZone.BoundingBox()) {
@@ -0,0 +1,73 @@
+package game
+
+import "math"
+
+type gridKey struct {
+	x, y int
+}
+
+// GroundGrid is a uniform grid over the level, every ground is stored in each cell its box covers. The cell size
+// matches the level tiles so a query for a player sized box only looks at a handful of cells.
+type GroundGrid struct {
+	cell    float64
+	cells   map[gridKey][]*Ground
+	marks   map[*Ground]int
+	query   int
+	results []*Ground
+}
+
+func NewGroundGrid(cell float64) *GroundGrid {
+	return &GroundGrid{
+		cell:  cell,
+		cells: map[gridKey][]*Ground{},
+		marks: map[*Ground]int{},
+	}
+}
+
+func (g *GroundGrid) Insert(ground *Ground) {
+	g.forCells(ground.BoundingBox(), func(key gridKey) {
+		g.cells[key] = append(g.cells[key], ground)
+	})
+}
+
+func (g *GroundGrid) Remove(ground *Ground) {
+	g.forCells(ground.BoundingBox(), func(key gridKey) {
+		list := g.cells[key]
+		for i, other := range list {
+			if other == ground {
+				g.cells[key] = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+	})
+	delete(g.marks, ground)
+}
+
+// Query returns every ground whose cells touch area, each one once and in a stable order. The returned slice is
+// reused by the next Query so it must not be kept.
+func (g *GroundGrid) Query(area Rect) []*Ground {
+	g.query++
+	g.results = g.results[:0]
+	g.forCells(area, func(key gridKey) {
+		for _, ground := range g.cells[key] {
+			if g.marks[ground] == g.query {
+				continue
+			}
+			g.marks[ground] = g.query
+			g.results = append(g.results, ground)
+		}
+	})
+	return g.results
+}
+
+func (g *GroundGrid) forCells(area Rect, fn func(key gridKey)) {
+	minX := int(math.Floor(math.Min(area.Left, area.Right) / g.cell))
+	maxX := int(math.Floor(math.Max(area.Left, area.Right) / g.cell))
+	minY := int(math.Floor(math.Min(area.Top, area.Bottom) / g.cell))
+	maxY := int(math.Floor(math.Max(area.Top, area.Bottom) / g.cell))
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			fn(gridKey{x: x, y: y})
+		}
+	}
+}
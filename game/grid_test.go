@@ -0,0 +1,51 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroundGrid(t *testing.T) {
+	grid := NewGroundGrid(50)
+	// wide spans eight cells, the others sit left of and above the origin in cells of their own
+	wide := NewGround(nil, Rect{Left: 10, Top: 10, Right: 190, Bottom: 90}, true, 1)
+	negative := NewGround(nil, Rect{Left: -120, Top: -80, Right: -60, Bottom: -30}, true, 1)
+	corner := NewGround(nil, Rect{Left: -40, Top: -40, Right: -10, Bottom: -10}, true, 1)
+	grid.Insert(wide)
+	grid.Insert(negative)
+	grid.Insert(corner)
+
+	query := func(area Rect) []*Ground {
+		return append([]*Ground(nil), grid.Query(area)...)
+	}
+	tests := []struct {
+		name string
+		area Rect
+		want []*Ground
+	}{
+		{"whole of wide", Rect{Left: 20, Top: 20, Right: 180, Bottom: 80}, []*Ground{wide}},
+		{"inverted corners", Rect{Left: 180, Top: 80, Right: 20, Bottom: 20}, []*Ground{wide}},
+		{"negative", Rect{Left: -110, Top: -70, Right: -100, Bottom: -60}, []*Ground{negative}},
+		{"across the origin", Rect{Left: -5, Top: -5, Right: 5, Bottom: 5}, []*Ground{corner, wide}},
+		{"everything", Rect{Left: -200, Top: -200, Right: 200, Bottom: 200}, []*Ground{negative, corner, wide}},
+		{"empty", Rect{Left: 300, Top: 300, Right: 400, Bottom: 400}, nil},
+	}
+	for _, test := range tests {
+		if got := query(test.area); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: query returned %d grounds %v, want %v", test.name, len(got), got, test.want)
+		}
+	}
+
+	grid.Remove(wide)
+	got := query(Rect{Left: -200, Top: -200, Right: 200, Bottom: 200})
+	if !reflect.DeepEqual(got, []*Ground{negative, corner}) {
+		t.Errorf("after removing wide the query returned %v", got)
+	}
+	for key, grounds := range grid.cells {
+		for _, g := range grounds {
+			if g == wide {
+				t.Errorf("removed ground still in cell %+v", key)
+			}
+		}
+	}
+}
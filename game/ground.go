@@ -1,8 +1,14 @@
 package game
 
+// Ground is a collider, Level.Build creates them without a texture from the merged ground cells while the
+// tiles are drawn separately. A one-way ground only stops things falling onto it from above and a sloped one is
+// only a floor whose height follows its SlopeShape.
 type Ground struct {
 	*RectObject
-	solid bool
+	solid  bool
+	oneWay bool
+	sloped bool
+	slope  SlopeShape
 }
 
 func NewGround(tex Drawable, area Rect, solid bool, scale float64) *Ground {
@@ -18,6 +24,9 @@ func (g *Ground) Layout(sw, sh float64) {
 }
 
 func (g *Ground) Draw(dst *Canvas) {
+	if g.texture == nil {
+		return
+	}
 
 	g.RectObject.Draw(dst)
 	//g.area.Draw(dst)
@@ -26,3 +35,11 @@ func (g *Ground) Draw(dst *Canvas) {
 func (g *Ground) Solid() bool {
 	return g.solid
 }
+
+func (g *Ground) OneWay() bool {
+	return g.oneWay
+}
+
+func (g *Ground) SetOneWay(oneWay bool) {
+	g.oneWay = oneWay
+}
@@ -0,0 +1,80 @@
+package game
+
+type HazardKind int
+
+const (
+	HazardSpikes HazardKind = iota
+	HazardLava
+	HazardWater
+	HazardKill
+)
+
+// drownInterval is how long the player can stay in water before losing the next point of health.
+const drownInterval = 1.0
+
+// Hazard hurts the player while its hitbox overlaps the player. The hitbox is only the dangerous part of the tile,
+// the bottom half for spikes and the area below the surface for liquids.
+type Hazard struct {
+	*RectObject
+	kind   HazardKind
+	hitbox Rect
+	player *Player
+	timer  float64
+}
+
+func NewHazard(kind HazardKind, tex Drawable, area Rect, player *Player) *Hazard {
+	hitbox := area
+	switch kind {
+	case HazardSpikes:
+		hitbox.Top += area.height() / 2
+	case HazardLava:
+		hitbox.Top += area.height() / 4
+	case HazardWater:
+		hitbox.Top += area.height() / 3
+	}
+
+	return &Hazard{
+		RectObject: NewRectObject(tex, area),
+		kind:       kind,
+		hitbox:     hitbox,
+		player:     player,
+	}
+}
+
+func (h *Hazard) Draw(dst *Canvas) {
+	if h.texture == nil {
+		return
+	}
+	h.RectObject.Draw(dst)
+}
+
+func (h *Hazard) Step(dt float64) {
+	if h.texture != nil {
+		h.RectObject.Step(dt)
+	}
+	if h.player.Dead() || !h.player.Hitbox().Overlaps(h.hitbox) {
+		h.timer = 0
+		return
+	}
+
+	switch h.kind {
+	case HazardSpikes:
+		h.player.Damage(1, DamageHazard)
+	case HazardWater:
+		h.timer += dt
+		if h.timer >= drownInterval {
+			h.timer = 0
+			h.player.Damage(1, DamageHazard)
+		}
+	case HazardLava, HazardKill:
+		h.player.Kill(DamageHazard)
+	}
+}
+
+func (h *Hazard) BoundingBox() Rect {
+	return h.hitbox
+}
+
+func (h *Hazard) Kind() HazardKind {
+	return h.kind
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"fmt"
+)
+
+// blankDrawable stands in for textures when a level runs without a window.
+type blankDrawable struct {
+	w, h float64
+}
+
+func (b blankDrawable) Update(dt float64) {
+}
+
+func (b blankDrawable) Draw(dst *Canvas) {
+}
+
+func (b blankDrawable) Size() (x, y float64) {
+	return b.w, b.h
+}
+
+func (b blankDrawable) Pivot() Vec {
+	return Vec{}
+}
+
+type blankAssets struct{}
+
+func (blankAssets) Texture(id Texture) Drawable {
+	return blankDrawable{w: 50, h: 50}
+}
+
+func (blankAssets) Animation(id Texture, atlas []byte) Drawable {
+	return blankDrawable{w: 50, h: 50}
+}
+
+// =====================================================================================================================
+
+// InputScript is the held actions for consecutive ticks, the last entry is held for every tick after it.
+type InputScript []ActionSet
+
+func Actions(actions ...Action) ActionSet {
+	var set ActionSet
+	for _, a := range actions {
+		set = set.With(a)
+	}
+	return set
+}
+
+// Hold appends ticks ticks of the given actions held down.
+func (s InputScript) Hold(ticks int, actions ...Action) InputScript {
+	set := Actions(actions...)
+	for i := 0; i < ticks; i++ {
+		s = append(s, set)
+	}
+	return s
+}
+
+func (s InputScript) At(tick int) ActionSet {
+	if len(s) == 0 {
+		return 0
+	}
+	if tick >= len(s) {
+		return s[len(s)-1]
+	}
+	return s[tick]
+}
+
+// =====================================================================================================================
+
+// Harness runs a level without a window, it is meant for playthrough tests and offline tools.
+type Harness struct {
+	level  *Level
+	world  *World
+	script InputScript
+	wins   int
+	loses  int
+}
+
+func NewHarness(levelData string, scale float64, seed int64, script InputScript) (*Harness, error) {
+	level, err := ParseLevel(levelData)
+	if err != nil {
+		return nil, fmt.Errorf("cant parse level for harness %w", err)
+	}
+	return newHarness(level, scale, seed, nil, script), nil
+}
+
+// newHarness builds the level, nil physics are the default player physics.
+func newHarness(level *Level, scale float64, seed int64, physics *PlayerPhysics, script InputScript) *Harness {
+	h := &Harness{level: level, script: script}
+	env := LevelEnv{
+		Assets:  blankAssets{},
+		Scale:   scale,
+		Seed:    seed,
+		Physics: physics,
+		Input:   func() ActionSet { return h.script.At(h.world.Ticks()) },
+		OnWin:   func() { h.wins++ },
+		OnLose:  func() { h.loses++ },
+	}
+	level.Build(env)
+	h.world = level.World()
+
+	return h
+}
+
+func NewReplayHarness(r *Replay) (*Harness, error) {
+	tiles, err := LevelMap(r.Level)
+	if err != nil {
+		return nil, fmt.Errorf("cant load replay level %w", err)
+	}
+	h := newHarness(NewLevel(tiles), r.Scale, r.Seed, r.Physics, r.Script())
+	if r.Continued != nil {
+		if err := h.level.Continue(*r.Continued); err != nil {
+			return nil, fmt.Errorf("cant continue the replayed run %w", err)
+		}
+	}
+	return h, nil
+}
+
+func (h *Harness) Step() {
+	h.world.Step()
+}
+
+func (h *Harness) Run(ticks int) {
+	for i := 0; i < ticks; i++ {
+		h.world.Step()
+	}
+}
+
+// RunUntil steps until done reports true or maxTicks pass, it reports whether done was reached.
+func (h *Harness) RunUntil(maxTicks int, done func(h *Harness) bool) bool {
+	for i := 0; i < maxTicks; i++ {
+		h.world.Step()
+		if done(h) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Harness) SetScript(script InputScript) {
+	h.script = script
+}
+
+func (h *Harness) Tick() int {
+	return h.world.Ticks()
+}
+
+func (h *Harness) Level() *Level {
+	return h.level
+}
+
+func (h *Harness) Player() *Player {
+	return h.level.player
+}
+
+func (h *Harness) PlayerBox() Rect {
+	return h.level.player.Hitbox()
+}
+
+func (h *Harness) PlayerPosition() (x, y float64) {
+	return h.level.player.area.Left, h.level.player.area.Top
+}
+
+func (h *Harness) Grounded() bool {
+	return h.level.player.Grounded()
+}
+
+func (h *Harness) NpcPositions() []Rect {
+	var boxes []Rect
+	for _, npc := range h.level.npcs {
+		boxes = append(boxes, npc.BoundingBox())
+	}
+	return boxes
+}
+
+func (h *Harness) Won() bool {
+	return h.wins > 0
+}
+
+func (h *Harness) Lost() bool {
+	return h.loses > 0
+}
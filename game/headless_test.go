@@ -0,0 +1,256 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+func levelHarness(t *testing.T, lvl int, script InputScript) *Harness {
+	t.Helper()
+	tiles, err := LevelMap(lvl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newHarness(NewLevel(tiles), 1, 1, nil, script)
+}
+
+func TestHarnessLevel1Playthrough(t *testing.T) {
+	h := levelHarness(t, 1, InputScript{}.Hold(1, ActionMoveRight, ActionJump))
+
+	npcs := h.NpcPositions()
+	if len(npcs) != 1 {
+		t.Fatalf("level 1 has %d npcs, want 1", len(npcs))
+	}
+	if !h.RunUntil(600, (*Harness).Won) {
+		x, y := h.PlayerPosition()
+		t.Fatalf("goal not reached after %d ticks, player at %v %v", h.Tick(), x, y)
+	}
+	t.Logf("won at tick %d", h.Tick())
+	if h.Lost() {
+		t.Error("the level was lost on the way to the goal")
+	}
+	if moved := h.NpcPositions(); moved[0] == npcs[0] {
+		t.Errorf("npc did not patrol, still at %v", moved[0])
+	}
+}
+
+func TestHarnessIdleSettlesOnGround(t *testing.T) {
+	h := levelHarness(t, 1, nil)
+	if !h.RunUntil(120, (*Harness).Grounded) {
+		t.Fatal("player never landed at the spawn")
+	}
+	x, y := h.PlayerPosition()
+	h.Run(60)
+	if !h.Grounded() {
+		t.Error("player left the ground without input")
+	}
+	if nx, ny := h.PlayerPosition(); nx != x || ny != y {
+		t.Errorf("idle player moved from %v %v to %v %v", x, y, nx, ny)
+	}
+	if h.Won() || h.Lost() {
+		t.Error("idling ended the level")
+	}
+}
+
+func TestHarnessLavaLosesEveryLife(t *testing.T) {
+	h, err := NewHarness("X P   G X\nXLLLLLLLX\nXXXXXXXXX", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.RunUntil(3000, (*Harness).Lost) {
+		t.Fatalf("player has %d lives left after %d ticks in lava", h.Player().Lives(), h.Tick())
+	}
+	if h.Won() {
+		t.Error("level won while losing it")
+	}
+	if lives := h.Player().Lives(); lives != 0 {
+		t.Errorf("lost with %d lives left", lives)
+	}
+	h.Run(60)
+	if h.loses != 1 {
+		t.Errorf("the level was lost %d times", h.loses)
+	}
+}
+
+// fixtureHarness runs a level of testdata at scale 1.
+func fixtureHarness(t *testing.T, name string, script InputScript) *Harness {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHarness(string(data), 1, 1, script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHarnessCheckpointRespawn(t *testing.T) {
+	h := fixtureHarness(t, "checkpoint.txt", InputScript{}.Hold(1, ActionMoveRight))
+	if !h.RunUntil(600, func(h *Harness) bool { return h.Player().Lives() < PlayerLives }) {
+		t.Fatal("player never reached the lava")
+	}
+	if cp := h.Level().ActiveCheckpoint(); cp != 0 {
+		t.Fatalf("active checkpoint %d, want 0", cp)
+	}
+	h.SetScript(nil)
+	h.Run(60)
+	checkpoint := levelRect(levelmap.Cell(5, 1))
+	if box := h.PlayerBox(); !box.Overlaps(checkpoint) {
+		t.Errorf("player respawned at %+v, not on the checkpoint %+v", box, checkpoint)
+	}
+}
+
+func TestLevelContinueAtCheckpoint(t *testing.T) {
+	h := fixtureHarness(t, "checkpoint.txt", nil)
+	if err := h.Level().Continue(SavedRun{Checkpoint: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if cp := h.Level().ActiveCheckpoint(); cp != 0 {
+		t.Errorf("continued at checkpoint %d, want 0", cp)
+	}
+	h.Run(60)
+	if box := h.PlayerBox(); !box.Overlaps(levelRect(levelmap.Cell(5, 1))) {
+		t.Errorf("continued run starts at %+v, not on the checkpoint", box)
+	}
+	if err := h.Level().Continue(SavedRun{Checkpoint: 3}); err == nil {
+		t.Error("continuing at a checkpoint the level does not have did not fail")
+	}
+}
+
+func TestHarnessHazards(t *testing.T) {
+	tests := []struct {
+		level  string
+		health int
+		lives  int
+		// after is the least number of ticks it takes, the player walks into the pit in a few
+		after int
+	}{
+		{"spikes.txt", PlayerMaxHealth - 1, PlayerLives, 0},
+		{"water.txt", PlayerMaxHealth - 1, PlayerLives, drownInterval * TickRate},
+		{"lava.txt", PlayerMaxHealth, PlayerLives - 1, 0},
+		{"killzone.txt", PlayerMaxHealth, PlayerLives - 1, 0},
+		{"fall.txt", PlayerMaxHealth, PlayerLives - 1, 0},
+	}
+	for _, test := range tests {
+		h := fixtureHarness(t, test.level, InputScript{}.Hold(1, ActionMoveRight))
+		hurt := func(h *Harness) bool {
+			return h.Player().Health() < PlayerMaxHealth || h.Player().Lives() < PlayerLives
+		}
+		if !h.RunUntil(600, hurt) {
+			t.Errorf("%s: player unharmed after %d ticks", test.level, h.Tick())
+			continue
+		}
+		if health, lives := h.Player().Health(), h.Player().Lives(); health != test.health || lives != test.lives {
+			t.Errorf("%s: player has %d health and %d lives, want %d and %d",
+				test.level, health, lives, test.health, test.lives)
+		}
+		if h.Tick() < test.after {
+			t.Errorf("%s: player hurt at tick %d, not before %d", test.level, h.Tick(), test.after)
+		}
+	}
+}
+
+func TestHarnessCollectibles(t *testing.T) {
+	// walking to the goal picks up everything on the ground but not the coin up in the air
+	h := fixtureHarness(t, "collectibles.txt", InputScript{}.Hold(1, ActionMoveRight))
+	if !h.RunUntil(600, (*Harness).Won) {
+		t.Fatalf("goal not reached after %d ticks", h.Tick())
+	}
+	want := Score{Collected: 4, Total: 5, Points: 8, MaxPoints: 9}
+	if score := h.Level().Score(); score != want {
+		t.Errorf("score %+v, want %+v", score, want)
+	}
+}
+
+func TestHarnessPlatformCarriesPlayer(t *testing.T) {
+	// the player spawns above the platform, which travels right along its track
+	h := fixtureHarness(t, "platform.txt", nil)
+	if !h.RunUntil(60, (*Harness).Grounded) {
+		t.Fatal("player never landed on the platform")
+	}
+	x, y := h.PlayerPosition()
+	h.Run(30)
+	if !h.Grounded() {
+		t.Fatal("player fell off the platform")
+	}
+	nx, ny := h.PlayerPosition()
+	if nx-x != 30*platformSpeed || ny != y {
+		t.Errorf("platform carried the player from %v %v to %v %v", x, y, nx, ny)
+	}
+}
+
+func TestHarnessWalkUpSlope(t *testing.T) {
+	// the goal is a tile above the spawn, the slope is the only way up without jumping
+	h := fixtureHarness(t, "slope.txt", InputScript{}.Hold(1, ActionMoveRight))
+	if !h.RunUntil(60, (*Harness).Grounded) {
+		t.Fatal("player never landed at the spawn")
+	}
+	_, y := h.PlayerPosition()
+	if !h.RunUntil(600, (*Harness).Won) {
+		x, y := h.PlayerPosition()
+		t.Fatalf("goal not reached after %d ticks, player at %v %v", h.Tick(), x, y)
+	}
+	if _, ny := h.PlayerPosition(); ny > y-50 {
+		t.Errorf("player walked from y %v to %v, not a tile up", y, ny)
+	}
+}
+
+func TestHarnessKeyOpensDoor(t *testing.T) {
+	h := fixtureHarness(t, "door.txt", InputScript{}.Hold(1, ActionMoveRight, ActionJump))
+	if !h.RunUntil(600, (*Harness).Won) {
+		x, y := h.PlayerPosition()
+		t.Fatalf("goal not reached after %d ticks, player at %v %v", h.Tick(), x, y)
+	}
+	state := h.Level().Mechanisms()
+	if !reflect.DeepEqual(state.Keys, []string{"gate"}) || len(state.Doors) == 0 {
+		t.Errorf("won with the mechanisms in %+v, want the key picked up and the door open", state)
+	}
+}
+
+func TestHarnessLockedDoor(t *testing.T) {
+	// the key is behind the door, only a continued run that already holds it gets through
+	script := InputScript{}.Hold(1, ActionMoveRight, ActionJump)
+	h := fixtureHarness(t, "locked.txt", script)
+	if h.RunUntil(600, (*Harness).Won) {
+		t.Fatalf("goal reached through a locked door at tick %d", h.Tick())
+	}
+
+	h = fixtureHarness(t, "locked.txt", script)
+	if err := h.Level().Continue(SavedRun{Mechanisms: MechanismState{Keys: []string{"gate"}}, Checkpoint: -1,
+		Left: h.PlayerBox().Left, Top: h.PlayerBox().Top}); err != nil {
+		t.Fatal(err)
+	}
+	if !h.RunUntil(600, (*Harness).Won) {
+		t.Errorf("goal not reached with the saved key after %d ticks", h.Tick())
+	}
+}
+
+func TestHarnessNoWinAfterGameOver(t *testing.T) {
+	h, err := NewHarness("X       X\nX P G   X\nXXXXXXXXX", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Run(30)
+	for h.Player().Lives() > 0 {
+		h.Player().Kill(DamageHazard)
+	}
+	// the last life is lost on the way into the goal
+	goal := levelRect(levelmap.Cell(4, 1))
+	h.Player().Move(goal.Left-h.PlayerBox().Left, 0)
+	if !h.PlayerBox().Overlaps(goal) {
+		t.Fatalf("player at %+v is not in the goal %+v", h.PlayerBox(), goal)
+	}
+	h.Run(10)
+	if h.Won() {
+		t.Error("level won by a player out of lives")
+	}
+	if !h.Lost() {
+		t.Error("level not lost with every life gone")
+	}
+}
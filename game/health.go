@@ -0,0 +1,87 @@
+package game
+
+type DamageSource int
+
+const (
+	DamageNpc DamageSource = iota
+	DamageHazard
+	DamageFall
+)
+
+const (
+	PlayerMaxHealth = 3
+	PlayerLives     = 3
+	// invulnerableTime is how long the player blinks and ignores damage after being hit or respawned.
+	invulnerableTime = 1.5
+	hurtTime         = 0.5
+)
+
+// Damage takes health away unless the player is still invulnerable from the last hit, it reports whether the
+// hit landed. Running out of health costs a life.
+func (p *Player) Damage(amount int, source DamageSource) bool {
+	if p.invulnerable > 0 || p.lives <= 0 {
+		return false
+	}
+
+	p.health -= amount
+	p.invulnerable = invulnerableTime
+	p.hurt = hurtTime
+	p.lastDamage = source
+	if p.health <= 0 {
+		p.LoseLife()
+	}
+	return true
+}
+
+// Kill costs a life right away, invulnerability does not protect against falling out of the world.
+func (p *Player) Kill(source DamageSource) {
+	if p.lives <= 0 {
+		return
+	}
+	p.health = 0
+	p.lastDamage = source
+	p.LoseLife()
+}
+
+func (p *Player) LoseLife() {
+	p.lives--
+	if p.lives <= 0 {
+		p.lives = 0
+		return
+	}
+	p.Respawn()
+}
+
+// Respawn puts the hitbox back at the respawn point with full health.
+func (p *Player) Respawn() {
+	box := p.Hitbox()
+	p.area = p.area.Offset(p.respawn.x-box.Left, p.respawn.y-box.Bottom)
+	p.velocity = Vec{}
+	p.health = PlayerMaxHealth
+	p.invulnerable = invulnerableTime
+	p.stun = 0
+	p.jumping = false
+	p.climbing = false
+	p.contacts = 0
+}
+
+// SetRespawn moves the respawn point so the bottom left of the hitbox lands at x, y.
+func (p *Player) SetRespawn(x, y float64) {
+	p.respawn = Vec{x: x, y: y}
+}
+
+func (p *Player) Health() int {
+	return p.health
+}
+
+func (p *Player) Lives() int {
+	return p.lives
+}
+
+func (p *Player) Invulnerable() bool {
+	return p.invulnerable > 0
+}
+
+func (p *Player) Dead() bool {
+	return p.lives <= 0
+}
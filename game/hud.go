@@ -0,0 +1,44 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font/opentype"
+)
+
+// Hud draws the level name, the player's health, lives, keys and the level score in screen space over the level.
+type Hud struct {
+	text  *Text
+	name  string
+	level *Level
+}
+
+func NewHud(fon *opentype.Font, fontSize float64, name string, level *Level) *Hud {
+	return &Hud{
+		text:  NewText(fon, "", fontSize, 0.15, 0.05),
+		name:  name,
+		level: level,
+	}
+}
+
+func (h *Hud) Draw(canvas *Canvas) {
+	player := h.level.player
+	score := h.level.Score()
+	h.text.lines = []string{
+		h.name,
+		fmt.Sprintf("Lives %d  Health %d/%d", player.Lives(), player.Health(), PlayerMaxHealth),
+		fmt.Sprintf("Score %d  Collected %d/%d", score.Points, score.Collected, score.Total),
+	}
+	if keys := h.level.Mechanisms().Keys; len(keys) > 0 {
+		h.text.lines = append(h.text.lines, "Keys "+strings.Join(keys, ", "))
+	}
+
+	savedMatrix := canvas.Transformation()
+	canvas.SetTransformation(ebiten.GeoM{})
+	h.text.Draw(canvas)
+	canvas.SetTransformation(savedMatrix)
+}
+
+func (h *Hud) Layout(_, _ float64) {}
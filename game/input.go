@@ -0,0 +1,276 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type Action int
+
+const (
+	ActionMoveLeft Action = iota
+	ActionMoveRight
+	ActionMoveUp
+	ActionMoveDown
+	ActionJump
+	ActionPause
+	ActionConfirm
+	ActionBack
+	ActionClick
+	ActionQuit
+	ActionDebugGameOver
+	actionCount
+)
+
+var actionNames = [actionCount]string{
+	ActionMoveLeft:      "MoveLeft",
+	ActionMoveRight:     "MoveRight",
+	ActionMoveUp:        "MoveUp",
+	ActionMoveDown:      "MoveDown",
+	ActionJump:          "Jump",
+	ActionPause:         "Pause",
+	ActionConfirm:       "Confirm",
+	ActionBack:          "Back",
+	ActionClick:         "Click",
+	ActionQuit:          "Quit",
+	ActionDebugGameOver: "DebugGameOver",
+}
+
+func (a Action) String() string {
+	if a < 0 || a >= actionCount {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+	return actionNames[a]
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= actionCount {
+		return nil, fmt.Errorf("unknown action %d", int(a))
+	}
+	return []byte(a.String()), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for i, name := range actionNames {
+		if name == string(text) {
+			*a = Action(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", string(text))
+}
+
+// =====================================================================================================================
+
+// ActionSet is a bit set of actions held down during a single tick.
+type ActionSet uint32
+
+func (s ActionSet) Has(a Action) bool {
+	return s&(1<<uint(a)) != 0
+}
+
+func (s ActionSet) With(a Action) ActionSet {
+	return s | 1<<uint(a)
+}
+
+// =====================================================================================================================
+
+type Input struct {
+	current  ActionSet
+	previous ActionSet
+}
+
+func NewInput() *Input {
+	return &Input{}
+}
+
+// Update advances the input by one tick, pressed holds every action that is down in the new tick.
+func (i *Input) Update(pressed ActionSet) {
+	i.previous = i.current
+	i.current = pressed
+}
+
+func (i *Input) Pressed(a Action) bool {
+	return i.current.Has(a)
+}
+
+func (i *Input) JustPressed(a Action) bool {
+	return i.current.Has(a) && !i.previous.Has(a)
+}
+
+func (i *Input) JustReleased(a Action) bool {
+	return !i.current.Has(a) && i.previous.Has(a)
+}
+
+func (i *Input) Current() ActionSet {
+	return i.current
+}
+
+// =====================================================================================================================
+
+type Binding struct {
+	Key           *ebiten.Key                   `json:"key,omitempty"`
+	Mouse         *ebiten.MouseButton           `json:"mouse,omitempty"`
+	GamepadButton *ebiten.StandardGamepadButton `json:"gamepadButton,omitempty"`
+	GamepadAxis   *ebiten.StandardGamepadAxis   `json:"gamepadAxis,omitempty"`
+	// AxisDirection is 1 or -1 and tells which half of GamepadAxis triggers the action.
+	AxisDirection float64 `json:"axisDirection,omitempty"`
+	Deadzone      float64 `json:"deadzone,omitempty"`
+}
+
+const defaultDeadzone = 0.25
+
+func KeyBinding(key ebiten.Key) Binding {
+	return Binding{Key: &key}
+}
+
+func MouseBinding(button ebiten.MouseButton) Binding {
+	return Binding{Mouse: &button}
+}
+
+func GamepadButtonBinding(button ebiten.StandardGamepadButton) Binding {
+	return Binding{GamepadButton: &button}
+}
+
+func GamepadAxisBinding(axis ebiten.StandardGamepadAxis, direction float64) Binding {
+	return Binding{GamepadAxis: &axis, AxisDirection: direction, Deadzone: defaultDeadzone}
+}
+
+func (b Binding) Pressed() bool {
+	if b.Key != nil && ebiten.IsKeyPressed(*b.Key) {
+		return true
+	}
+	if b.Mouse != nil && ebiten.IsMouseButtonPressed(*b.Mouse) {
+		return true
+	}
+	return false
+}
+
+func (b Binding) PressedOn(id ebiten.GamepadID) bool {
+	if b.GamepadButton != nil && ebiten.IsStandardGamepadButtonPressed(id, *b.GamepadButton) {
+		return true
+	}
+	if b.GamepadAxis != nil {
+		deadzone := b.Deadzone
+		if deadzone <= 0 {
+			deadzone = defaultDeadzone
+		}
+		if ebiten.StandardGamepadAxisValue(id, *b.GamepadAxis)*b.AxisDirection > deadzone {
+			return true
+		}
+	}
+	return false
+}
+
+// =====================================================================================================================
+
+type Bindings map[Action][]Binding
+
+func DefaultBindings() Bindings {
+	return Bindings{
+		ActionMoveLeft: {
+			KeyBinding(ebiten.KeyArrowLeft),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonLeftLeft),
+			GamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickHorizontal, -1),
+		},
+		ActionMoveRight: {
+			KeyBinding(ebiten.KeyArrowRight),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonLeftRight),
+			GamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickHorizontal, 1),
+		},
+		ActionMoveUp: {
+			KeyBinding(ebiten.KeyArrowUp),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonLeftTop),
+			GamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickVertical, -1),
+		},
+		ActionMoveDown: {
+			KeyBinding(ebiten.KeyArrowDown),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonLeftBottom),
+			GamepadAxisBinding(ebiten.StandardGamepadAxisLeftStickVertical, 1),
+		},
+		ActionJump: {
+			KeyBinding(ebiten.KeyArrowUp),
+			KeyBinding(ebiten.KeySpace),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonRightBottom),
+		},
+		ActionPause: {
+			KeyBinding(ebiten.KeyEscape),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonCenterRight),
+		},
+		ActionConfirm: {
+			KeyBinding(ebiten.KeyEnter),
+			GamepadButtonBinding(ebiten.StandardGamepadButtonRightBottom),
+		},
+		ActionBack:          {GamepadButtonBinding(ebiten.StandardGamepadButtonRightRight)},
+		ActionClick:         {MouseBinding(ebiten.MouseButtonLeft)},
+		ActionQuit:          {KeyBinding(ebiten.Key0)},
+		ActionDebugGameOver: {KeyBinding(ebiten.Key1)},
+	}
+}
+
+// Poll reads the keyboard, the mouse and every connected gamepad. Whichever device was used is reported to
+// the controllers so the active device follows the player.
+func (b Bindings) Poll(c *Controllers) ActionSet {
+	var pressed ActionSet
+	keyboard := false
+	gamepads := map[ebiten.GamepadID]bool{}
+	for action, bindings := range b {
+		for _, binding := range bindings {
+			if binding.Pressed() {
+				pressed = pressed.With(action)
+				keyboard = true
+			}
+			for _, id := range c.Gamepads() {
+				if binding.PressedOn(id) {
+					pressed = pressed.With(action)
+					gamepads[id] = true
+				}
+			}
+		}
+	}
+
+	c.use(keyboard, gamepads)
+	return pressed
+}
+
+func (b Bindings) Bind(action Action, bindings ...Binding) {
+	b[action] = bindings
+}
+
+func LoadBindings(filename string) (Bindings, error) {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cant read bindings %w", err)
+	}
+
+	b := Bindings{}
+	if err := json.Unmarshal(file, &b); err != nil {
+		return nil, fmt.Errorf("cant parse bindings %w", err)
+	}
+	b.merge(DefaultBindings())
+	return b, nil
+}
+
+// merge gives the actions missing from b their default bindings, so a saved file picks up actions added after it
+// was written. The bindings of actions in the file are the player's, defaults they removed stay removed.
+func (b Bindings) merge(defaults Bindings) {
+	for action, bindings := range defaults {
+		if _, ok := b[action]; !ok {
+			b[action] = bindings
+		}
+	}
+}
+
+func (b Bindings) Save(filename string) error {
+	file, err := json.MarshalIndent(b, "", " ")
+	if err != nil {
+		return fmt.Errorf("cant encode bindings %w", err)
+	}
+	if err := os.WriteFile(filename, file, 0644); err != nil {
+		return fmt.Errorf("cant write bindings %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestLoadBindingsKeepsRemovedDefaults(t *testing.T) {
+	// the player took ArrowUp off Jump, the file is from before Pause existed
+	saved := DefaultBindings()
+	saved.Bind(ActionJump, KeyBinding(ebiten.KeySpace), GamepadButtonBinding(ebiten.StandardGamepadButtonRightBottom))
+	delete(saved, ActionPause)
+	filename := filepath.Join(t.TempDir(), "controls.json")
+	if err := saved.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := LoadBindings(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b[ActionJump], saved[ActionJump]) {
+		t.Errorf("jump is bound to %+v, saved %+v", b[ActionJump], saved[ActionJump])
+	}
+	if !reflect.DeepEqual(b[ActionPause], DefaultBindings()[ActionPause]) {
+		t.Errorf("pause missing from the file did not get its defaults, got %+v", b[ActionPause])
+	}
+}
@@ -0,0 +1,92 @@
+package game
+
+// climbSpeed is how far the player climbs per tick, in unscaled pixels.
+const climbSpeed = 4.0
+
+// ladderAt returns the ladder the player can grab, the middle of the hitbox has to be over it and the feet have to
+// be on it or right on top of it, so Down on the top of a ladder starts climbing down.
+func (p *Player) ladderAt() (Rect, bool) {
+	box := p.Hitbox()
+	mid := center(box).x
+	for _, ladder := range p.ladders {
+		if mid < ladder.Left || mid > ladder.Right {
+			continue
+		}
+		if box.Top < ladder.Bottom && box.Bottom+touchEpsilon >= ladder.Top {
+			return ladder, true
+		}
+	}
+	return Rect{}, false
+}
+
+// startClimb attaches the player to a ladder when Up is pressed in front of it or Down is pressed above it.
+func (p *Player) startClimb() bool {
+	ladder, ok := p.ladderAt()
+	if !ok {
+		return false
+	}
+	box := p.Hitbox()
+	up := p.input.Pressed(ActionMoveUp) && box.Bottom > ladder.Top+touchEpsilon
+	down := p.input.Pressed(ActionMoveDown) && box.Bottom < ladder.Bottom-touchEpsilon
+	if !up && !down {
+		return false
+	}
+
+	p.climbing = true
+	p.jumping = false
+	p.velocity = Vec{}
+	p.Move(center(ladder).x-center(box).x, 0)
+	return true
+}
+
+// climb moves the player along the ladder without gravity. Jumping lets go of the ladder, as does reaching its top
+// or standing on the floor at its bottom. Up is also a jump key, so a jump only counts when Up is not held.
+func (p *Player) climb(dt float64) {
+	p.velocity = Vec{}
+	if p.input.JustPressed(ActionJump) && !p.input.Pressed(ActionMoveUp) {
+		p.Action()
+		p.climbing = false
+		p.startJump(false, dt*TickRate)
+		return
+	}
+
+	ladder, ok := p.ladderAt()
+	if !ok {
+		p.climbing = false
+		return
+	}
+
+	dy := 0.0
+	if p.input.Pressed(ActionMoveUp) {
+		dy = -climbSpeed * p.scale * dt * TickRate
+	} else if p.input.Pressed(ActionMoveDown) {
+		dy = climbSpeed * p.scale * dt * TickRate
+	}
+	if dy != 0 {
+		p.Action()
+	}
+
+	box := p.Hitbox()
+	if box.Bottom+dy <= ladder.Top {
+		// Up is a jump key too, the player should not jump off the top while still holding it
+		dy = ladder.Top - box.Bottom
+		p.climbing = false
+		p.jumpLock = true
+	}
+	p.Move(0, dy)
+	if dy > 0 && p.contacts.Has(ContactFloor) {
+		p.climbing = false
+	}
+
+	if p.onClimb != nil {
+		p.onClimb()
+	}
+}
+
+func (p *Player) SetLadders(ladders []Rect) {
+	p.ladders = ladders
+}
+
+func (p *Player) Climbing() bool {
+	return p.climbing
+}
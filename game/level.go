@@ -1,136 +1,167 @@
 package game
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
-	"strings"
 
 	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/assets"
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
 )
 
-type LevelComponent rune
+var slopeShapes = map[levelmap.Component]SlopeShape{
+	levelmap.SlopeUp:        SlopeUp,
+	levelmap.SlopeDown:      SlopeDown,
+	levelmap.GentleUpLow:    SlopeGentleUpLow,
+	levelmap.GentleUpHigh:   SlopeGentleUpHigh,
+	levelmap.GentleDownHigh: SlopeGentleDownHigh,
+	levelmap.GentleDownLow:  SlopeGentleDownLow,
+}
 
-const (
-	LevelSpace   LevelComponent = ' '
-	LevelGround  LevelComponent = 'X'
-	LevelPlayer  LevelComponent = 'P'
-	LevelGoal    LevelComponent = 'G'
-	LevelRaccoon LevelComponent = 'R'
-)
+// LevelAssets hands out the drawables a level is built from, the game uses its TextureManager and the headless
+// harness uses blank placeholders.
+type LevelAssets interface {
+	Texture(id Texture) Drawable
+	Animation(id Texture, atlas []byte) Drawable
+}
 
-type Level struct {
-	raster [][]LevelComponent
-	res    []Renderable
-	npcs   []*Npc
-	player *Player
-	goal   *RectObject
+type LevelEnv struct {
+	Assets      LevelAssets
+	Scale       float64
+	Seed        int64
+	Physics     *PlayerPhysics
+	Backgrounds []Texture
+	Input       func() ActionSet
+	OnWin       func()
+	OnLose      func()
 }
 
-func ParseLevel(str string) (*Level, error) {
+type Level struct {
+	tiles       *levelmap.Map
+	res         []Renderable
+	npcs        []*Npc
+	player      *Player
+	goal        *RectObject
+	checkpoints []*Checkpoint
+	hazards     []*Hazard
+	pickups     []*Collectible
+	platforms   []*MovingPlatform
+	slopes      []*Ground
+	links       map[string]*Link
+	keys        []*Key
+	doors       []*Door
+	switches    []*Switch
+	blocks      []*Block
+	score       Score
+	world       *World
+	grid        *GroundGrid
+}
 
-	lines := strings.Split(str, "\n")
-	if len(lines) < 2 {
-		return nil, fmt.Errorf("level must have at least 2 lines %d ", len(lines))
+// LevelMap reads a level of the embedded campaign.
+func LevelMap(lvl int) (*levelmap.Map, error) {
+	campaign, err := DefaultCampaign()
+	if err != nil {
+		return nil, err
 	}
+	return campaign.LevelMap(lvl)
+}
 
-	width := len(lines[0])
-	if width < 1 {
-		return nil, fmt.Errorf("level must have at least a single column to work %d", width)
+// ParseLevel reads a level in the JSON or the ASCII format, see levelmap.Parse.
+func ParseLevel(str string) (*Level, error) {
+	tiles, err := levelmap.Parse(str)
+	if err != nil {
+		return nil, err
 	}
+	return NewLevel(tiles), nil
+}
 
-	raster := make([][]LevelComponent, len(lines), len(lines))
+func NewLevel(tiles *levelmap.Map) *Level {
+	return &Level{tiles: tiles}
+}
 
-	hasPlayer := false
-	for lineNo, line := range lines {
-		if len(line) != width {
-			return nil, fmt.Errorf("the width of the lvl must be same, the line %d has a wdith of %d expected %d", lineNo, len(line), width)
-		}
+func (l *Level) Build(env LevelEnv) []Renderable {
+	l.world = NewWorld(env.Input)
+	scale := env.Scale
+	rng := rand.New(rand.NewSource(env.Seed))
 
-		raster[lineNo] = make([]LevelComponent, width, width)
-		for runeNo, char := range line {
-			switch LevelComponent(char) {
-			case LevelPlayer:
-				if hasPlayer {
-					return nil, fmt.Errorf("second player declaration in %d line and %d column", lineNo, runeNo)
-				}
-				hasPlayer = true
-				fallthrough
-			case LevelGround:
-				fallthrough
-			case LevelGoal:
-				fallthrough
-			case LevelRaccoon:
-				fallthrough
-			case LevelSpace:
-				raster[lineNo][runeNo] = LevelComponent(char)
-			}
-		}
+	backgrounds := env.Backgrounds
+	if len(backgrounds) == 0 {
+		backgrounds = backgroundSets["town"]
+	}
+	for _, background := range backgrounds {
+		l.res = append(l.res, NewBackground(env.Assets.Texture(background)))
 	}
 
-	return &Level{raster: raster}, nil
-}
-
-func (l *Level) Build(g *Game) []Renderable {
-	l.res = append(l.res, NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundImageT))))
-	l.res = append(l.res, NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownFrontT))))
-	l.res = append(l.res, NewBackground(NewDrawableTexture(g.texture.LoadTexture(BackgroundTownT))))
-
-	for y, line := range l.raster {
+	for y, line := range l.tiles.Tiles {
 		for x, component := range line {
-			pos := Vec{
-				x: float64(x) * 50,
-				y: float64(y) * 50,
+			cell := levelRect(levelmap.Cell(x, y))
+			for _, sprite := range l.tiles.TerrainSprites(x, y, rng) {
+				l.res = append(l.res, NewRectObject(env.Assets.Texture(tileTextures[sprite.Tile]), levelRect(sprite.Area).Scale(scale)))
 			}
-			cell := Rect{pos.x, pos.y, pos.x + 50, pos.y + 50}
 			switch component {
-			case LevelPlayer:
-				l.player = l.NewPlayerObj(g, cell)
-			case LevelGoal:
-				l.goal = NewRectObject(NewDrawableTexture(g.texture.LoadTexture(ButtonNoTextT)), cell)
-			case LevelGround:
-				var tex Texture
-				var texList []Texture
-				aboveGroundCount := 0
-				for i := 1; i <= 3; i++ {
-					if l.Get(x, y-i) == LevelGround {
-						aboveGroundCount++
-					} else if l.Get(x, y-i) == LevelSpace {
-						break
-					}
+			case levelmap.Spikes:
+				l.hazards = append(l.hazards, NewHazard(HazardSpikes, env.Assets.Texture(SpikesT), cell.Scale(scale), nil))
+			case levelmap.Lava:
+				l.hazards = append(l.hazards, NewHazard(HazardLava, env.Assets.Texture(LavaT), cell.Scale(scale), nil))
+			case levelmap.Water:
+				l.hazards = append(l.hazards, NewHazard(HazardWater, env.Assets.Texture(WaterT), cell.Scale(scale), nil))
+			case levelmap.KillZone:
+				l.hazards = append(l.hazards, NewHazard(HazardKill, nil, cell.Scale(scale), nil))
+			case levelmap.Platform, levelmap.OneWayPlatform:
+				width := l.tiles.PlatformRun(x, y)
+				if width == 0 {
+					break
+				}
+				tex := PlatformT
+				if component == levelmap.OneWayPlatform {
+					tex = PlatformOneWayT
+				}
+				area := Rect{cell.Left, cell.Top, cell.Left + float64(width)*50, cell.Top + platformHeight}
+				var path []Vec
+				for _, point := range l.tiles.PlatformPath(x, y, width) {
+					path = append(path, Vec{x: point.X, y: point.Y})
 				}
-				if aboveGroundCount == 3 {
-					randomNumber := rand.Intn(30) + 1
-					if randomNumber >= 1 && randomNumber <= 19 {
-						tex = GroundFillDeeperT
-					} else if randomNumber >= 20 && randomNumber <= 27 {
-						tex = GroundFillDeeper2T
-					} else if randomNumber >= 28 {
-						tex = GroundFillDeeper3T
-					}
-				} else if aboveGroundCount == 2 {
-					texList = append(texList, GroundFillDeepLeftT, GroundFillDeepRightT, GroundFillDeepSingleT, GroundFillDeepT)
-					tex = l.CheckTilePlacement(x, y, texList)
-				} else if aboveGroundCount == 1 {
-					texList = append(texList, GroundFillLeftT, GroundFillRightT, GroundFillSingleT, GroundFillT)
-					tex = l.CheckTilePlacement(x, y, texList)
-
-				} else {
-					texList = append(texList, GroundLeftT, GroundRightT, GroundSingleT, GroundMidT)
-					tex = l.CheckTilePlacement(x, y, texList)
+				l.platforms = append(l.platforms, NewMovingPlatform(env.Assets.Texture(tex), area, path, component == levelmap.OneWayPlatform, scale))
+			default:
+				if shape, ok := slopeShapes[component]; ok {
+					l.slopes = append(l.slopes, NewSlope(cell, shape, scale))
 				}
+			}
+		}
+	}
 
-				ground := NewGround(NewDrawableTexture(g.texture.LoadTexture(tex)), cell, true, g.engine.Scale())
-				l.res = append(l.res, ground)
-			case LevelRaccoon:
-				min, max := l.GroundGroup(x, y, cell.Scale(g.engine.Scale()))
-				anim := NewDrawableTexture(g.texture.LoadTexture(ButtonContinueT))
-				r := NewNpc(anim, cell.Scale(g.engine.Scale()), g.engine.Scale(), 2, 15, 10, min, max, false)
-				l.npcs = append(l.npcs, r)
+	// decoration is drawn over the terrain and under everything else
+	for y, line := range l.tiles.Decor {
+		for x, tile := range line {
+			if tile != "" {
+				l.res = append(l.res, NewRectObject(env.Assets.Texture(tileTextures[tile]), levelRect(levelmap.Cell(x, y)).Scale(scale)))
 			}
 		}
 	}
 
+	for _, o := range l.tiles.Objects {
+		cell := levelRect(levelmap.Cell(o.X, o.Y))
+		switch o.Type {
+		case levelmap.ObjectPlayer:
+			l.player = l.NewPlayerObj(env, cell)
+		case levelmap.ObjectGoal:
+			l.goal = NewRectObject(env.Assets.Texture(ButtonNoTextT), cell)
+		case levelmap.ObjectNpc:
+			l.npcs = append(l.npcs, l.NewNpcObj(env, o, cell.Scale(scale)))
+		case levelmap.ObjectCheckpoint:
+			c := NewCheckpoint(env.Assets.Texture(ButtonOffT), env.Assets.Texture(ButtonOnT), cell.Scale(scale), nil)
+			c.onActivate = l.activateCheckpoint
+			l.checkpoints = append(l.checkpoints, c)
+		case levelmap.ObjectCoin:
+			l.addCollectible(NewCollectible(CollectibleCoin, env.Assets.Texture(CoinT), cell.Scale(scale), nil))
+		case levelmap.ObjectGem:
+			l.addCollectible(NewCollectible(CollectibleGem, env.Assets.Texture(GemT), cell.Scale(scale), nil))
+		default:
+			l.addMechanism(env, o, cell.Scale(scale))
+		}
+	}
+
 	if l.goal == nil {
 		panic(fmt.Errorf("invalid state, no goal"))
 	}
@@ -140,95 +171,257 @@ func (l *Level) Build(g *Game) []Renderable {
 		l.player.npcs = append(l.player.npcs, npc)
 	}
 
-	for _, r := range l.res {
-		if ground, ok := r.(*Ground); ok {
-			for _, npc := range l.npcs {
-				npc.RegisterGround(ground)
-			}
-			l.player.AppendGround(ground)
-		}
+	l.grid = NewGroundGrid(50 * scale)
+	for _, area := range l.tiles.MergedGround() {
+		l.grid.Insert(NewGround(nil, levelRect(area), true, scale))
+	}
+	for _, slope := range l.slopes {
+		l.grid.Insert(slope)
+	}
+	for _, area := range l.tiles.MergedOneWay() {
+		ground := NewGround(nil, levelRect(area), true, scale)
+		ground.SetOneWay(true)
+		l.grid.Insert(ground)
+	}
+	// the top of a ladder can be stood on and dropped through like a one-way platform
+	var ladders []Rect
+	for _, ladder := range l.tiles.Ladders() {
+		area := levelRect(ladder)
+		top := NewGround(nil, Rect{area.Left, area.Top, area.Right, area.Top + platformHeight}, true, scale)
+		top.SetOneWay(true)
+		l.grid.Insert(top)
+		ladders = append(ladders, area.Scale(scale))
 	}
+	l.player.SetLadders(ladders)
+	for _, platform := range l.platforms {
+		platform.SetGrid(l.grid)
+		platform.player = l.player
+		platform.npcs = l.npcs
+		l.grid.Insert(platform.Ground)
+		l.res = append(l.res, platform)
+	}
+	for _, d := range l.doors {
+		d.player = l.player
+		l.grid.Insert(d.Ground)
+		l.res = append(l.res, d)
+	}
+	for _, b := range l.blocks {
+		l.grid.Insert(b.Ground)
+		l.res = append(l.res, b)
+	}
+	for _, npc := range l.npcs {
+		npc.SetGrid(l.grid)
+	}
+	l.player.SetGrid(l.grid)
 	l.res = append(l.res, l.goal)
+	for _, c := range l.checkpoints {
+		c.player = l.player
+		l.res = append(l.res, c)
+	}
+	for _, h := range l.hazards {
+		h.player = l.player
+		l.res = append(l.res, h)
+	}
+	for _, c := range l.pickups {
+		c.player = l.player
+		l.res = append(l.res, c)
+	}
+	for _, k := range l.keys {
+		k.player = l.player
+		l.res = append(l.res, k)
+	}
+	for _, s := range l.switches {
+		s.player = l.player
+		s.npcs = l.npcs
+		l.res = append(l.res, s)
+	}
 
 	l.res = append(l.res, l.player)
 
-	l.res = append(l.res, NewGoal(l.player, l.goal, g.engine.Scale(), func() { g.engine.ChangePlayerLvL(2) }, func() { g.engine.ChangeStage(0) }))
+	goal := NewGoal(l.player, l.goal, l.KillBounds().Scale(scale), env.OnWin)
+	l.player.onGameOver = env.OnLose
+	l.res = append(l.res, goal)
+
+	for _, platform := range l.platforms {
+		l.world.Add(platform)
+	}
+	for _, b := range l.blocks {
+		l.world.Add(b)
+	}
+	for _, npc := range l.npcs {
+		l.world.Add(npc)
+	}
+	l.world.Add(l.player)
+	for _, c := range l.checkpoints {
+		l.world.Add(c)
+	}
+	for _, h := range l.hazards {
+		l.world.Add(h)
+	}
+	for _, c := range l.pickups {
+		l.world.Add(c)
+	}
+	for _, k := range l.keys {
+		l.world.Add(k)
+	}
+	for _, d := range l.doors {
+		l.world.Add(d)
+	}
+	for _, s := range l.switches {
+		l.world.Add(s)
+	}
+	l.world.Add(goal)
 
 	return l.res
 }
 
-func (l *Level) Get(x, y int) LevelComponent {
-	if x < 0 || y < 0 || y >= len(l.raster) || x >= len(l.raster[0]) {
-		return LevelSpace
+func (l *Level) World() *World {
+	return l.world
+}
+
+// Map is the description the level was built from.
+func (l *Level) Map() *levelmap.Map {
+	return l.tiles
+}
+
+// levelRect converts an area of a levelmap into an unscaled Rect.
+func levelRect(r levelmap.Rect) Rect {
+	return Rect{Left: r.Left, Top: r.Top, Right: r.Right, Bottom: r.Bottom}
+}
+
+func (l *Level) addCollectible(c *Collectible) {
+	c.onCollect = l.collect
+	l.pickups = append(l.pickups, c)
+	l.score.Total++
+	l.score.MaxPoints += c.kind.Points()
+}
+
+func (l *Level) collect(c *Collectible) {
+	l.score.Collected++
+	l.score.Points += c.kind.Points()
+}
+
+func (l *Level) Score() Score {
+	return l.score
+}
+
+// KillBounds is the area the player has to stay in, in unscaled level coordinates. It is the level grown by a
+// tile on the sides and below, so the player falls out of view before dying, and it is open towards the top.
+func (l *Level) KillBounds() Rect {
+	height := float64(l.tiles.Height())
+	width := float64(l.tiles.Width())
+	return Rect{
+		Left:   -50,
+		Top:    math.Inf(-1),
+		Right:  (width + 1) * 50,
+		Bottom: (height + 1) * 50,
 	}
-	return l.raster[y][x]
 }
 
-func (l *Level) CheckLeftSpace(x, y int) bool {
-	if l.Get(x-1, y) == LevelSpace && l.Get(x+1, y) == LevelGround {
-		return true
-	} else {
-		return false
+func (l *Level) activateCheckpoint(active *Checkpoint) {
+	for _, c := range l.checkpoints {
+		if c != active {
+			c.SetActive(false)
+		}
 	}
 }
 
-func (l *Level) CheckRightSpace(x, y int) bool {
-	if l.Get(x-1, y) == LevelGround && l.Get(x+1, y) == LevelSpace {
-		return true
-	} else {
-		return false
+// ActiveCheckpoint returns the index of the active checkpoint in reading order, or -1 when none was reached yet.
+func (l *Level) ActiveCheckpoint() int {
+	for i, c := range l.checkpoints {
+		if c.Active() {
+			return i
+		}
 	}
+	return -1
 }
 
-func (l *Level) CheckTilePlacement(x, y int, texList []Texture) Texture {
-	if l.CheckLeftSpace(x, y) {
-		return texList[0]
-	} else if l.CheckRightSpace(x, y) {
-		return texList[1]
-	} else if l.Get(x+1, y) == LevelSpace && l.Get(x-1, y) == LevelSpace {
-		return texList[2]
+// RestoreCheckpoint activates the checkpoint with the given index and puts the player on it.
+func (l *Level) RestoreCheckpoint(idx int) error {
+	if idx < 0 || idx >= len(l.checkpoints) {
+		return fmt.Errorf("level has no checkpoint %d", idx)
 	}
-	return texList[3]
+	l.checkpoints[idx].Activate()
+	l.player.Respawn()
+	return nil
 }
 
-func (l *Level) GroundGroup(x, y int, cell Rect) (float64, float64) {
-	min := float64(x) * 100
-	max := float64(x)*100 + cell.width()
+// SavedRun is where a continued run starts in its level: the keys, doors and levers of the save and its
+// checkpoint, or for saves from before checkpoints the position of the player.
+type SavedRun struct {
+	Mechanisms MechanismState `json:"mechanisms"`
+	Checkpoint int            `json:"checkpoint"`
+	Left       float64        `json:"left"`
+	Top        float64        `json:"top"`
+}
 
-	for i := 1; i < 10; i++ {
-		if l.Get(x-i, y) != LevelGround {
-			if l.Get(x-i, y+1) == LevelGround {
-				min -= cell.width()
-			} else if l.Get(x-i, y+1) == LevelSpace {
-				break
-			}
+// Continue puts a freshly built level into the state of the saved run. What cannot be restored is reported, the
+// rest is restored anyway and a missing checkpoint falls back to the saved position.
+func (l *Level) Continue(run SavedRun) error {
+	var errs []error
+	if err := l.RestoreMechanisms(run.Mechanisms); err != nil {
+		errs = append(errs, fmt.Errorf("cant restore keys, doors and levers %w", err))
+	}
+	if run.Checkpoint >= 0 {
+		err := l.RestoreCheckpoint(run.Checkpoint)
+		if err == nil {
+			return errors.Join(errs...)
 		}
+		errs = append(errs, fmt.Errorf("cant restore checkpoint, using the saved position %w", err))
 	}
+	l.player.area = l.player.area.Offset(run.Left-l.player.area.Left, run.Top-l.player.area.Top)
+	return errors.Join(errs...)
+}
 
-	for i := 1; i < 10; i++ {
-		if l.Get(x+i, y) != LevelGround {
-			if l.Get(x+i, y+1) == LevelGround {
-				max += cell.width()
-			} else if l.Get(x+i, y+1) == LevelSpace {
-				break
-			}
-		}
+// NewNpcObj creates the npc of o, its properties override the defaults. Without a patrol range the npc walks
+// until the ground ends.
+func (l *Level) NewNpcObj(env LevelEnv, o levelmap.Object, cell Rect) *Npc {
+	props := o.Props
+	speed, stunDuration, pushPower, target := NpcSpeed, NpcStunDuration, NpcPushPower, false
+	if props.Speed != nil {
+		speed = *props.Speed
+	}
+	if props.StunDuration != nil {
+		stunDuration = *props.StunDuration
+	}
+	if props.PushPower != nil {
+		pushPower = *props.PushPower
 	}
+	if props.Target != nil {
+		target = *props.Target
+	}
+
+	left, right := l.tiles.GroundGroup(o.X, o.Y)
+	min := float64(o.X)*100 - float64(left)*cell.width()
+	max := float64(o.X)*100 + cell.width() + float64(right)*cell.width()
 
-	return min, max
+	anim := env.Assets.Texture(ButtonContinueT)
+	npc := NewNpc(anim, cell, env.Scale, speed, stunDuration, pushPower, min, max, target)
+	if props.PatrolLeft != nil || props.PatrolRight != nil {
+		if props.PatrolLeft != nil {
+			left = *props.PatrolLeft
+		}
+		if props.PatrolRight != nil {
+			right = *props.PatrolRight
+		}
+		npc.SetPatrol(cell.Left-float64(left)*cell.width(), cell.Right+float64(right)*cell.width())
+	}
+	return npc
 }
 
-func (l *Level) NewPlayerObj(g *Game, cell Rect) *Player {
+func (l *Level) NewPlayerObj(env LevelEnv, cell Rect) *Player {
 	mortyCell := cell
 	mortyCell.Right *= 1.05
 
 	anim := NewAnimationGroup(
-		l.MustParseAnimation(g, MortyVanillaT, assets.MortyVanillaAtlas),
-		l.MustParseAnimation(g, MortyJumpingT, assets.MortyJumpingAtlas),
-		l.MustParseAnimation(g, MortyWalkingT, assets.MortyWalkingAtlas),
-		l.MustParseAnimation(g, MortyMeditatingT, assets.MortyMeditatingAtlas),
-		l.MustParseAnimation(g, MortySadFulT, assets.MortySadFulAtlas),
-		l.MustParseAnimation(g, MortyJoyFulT, assets.MortyJoyFulAtlas),
+		env.Assets.Animation(MortyVanillaT, assets.MortyVanillaAtlas),
+		env.Assets.Animation(MortyJumpingT, assets.MortyJumpingAtlas),
+		env.Assets.Animation(MortyWalkingT, assets.MortyWalkingAtlas),
+		env.Assets.Animation(MortyMeditatingT, assets.MortyMeditatingAtlas),
+		env.Assets.Animation(MortySadFulT, assets.MortySadFulAtlas),
+		env.Assets.Animation(MortyJoyFulT, assets.MortyJoyFulAtlas),
+		// there is no climbing sprite sheet yet, the walking animation stands in for it
+		env.Assets.Animation(MortyWalkingT, assets.MortyWalkingAtlas),
 	)
 
 	const (
@@ -238,9 +431,13 @@ func (l *Level) NewPlayerObj(g *Game, cell Rect) *Player {
 		Meditating
 		SadFul
 		JoyFul
+		Climbing
 	)
 
-	p := NewPlayer(anim, mortyCell.Scale(g.engine.Scale()), g.engine.Scale())
+	p := NewPlayer(anim, mortyCell.Scale(env.Scale), env.Scale, l.world.Input())
+	if env.Physics != nil {
+		p.SetPhysics(*env.Physics)
+	}
 
 	p.onMovement = func() {
 		anim.idx = Walking
@@ -258,14 +455,17 @@ func (l *Level) NewPlayerObj(g *Game, cell Rect) *Player {
 		anim.idx = Meditating
 	}
 
-	return p
-}
+	p.onHurt = func() {
+		anim.idx = SadFul
+	}
 
-func (l *Level) MustParseAnimation(g *Game, id Texture, xml []byte) *TextureAnimation {
-	atl, err := ParseTextureAtlas(bytes.NewReader(xml))
-	if err != nil {
-		panic(fmt.Errorf("invalid state by parsing texture atlas %w", err))
+	p.onCheer = func() {
+		anim.idx = JoyFul
 	}
 
-	return NewTextureAnimation(g.texture.LoadTexture(id), atl, 30)
+	p.onClimb = func() {
+		anim.idx = Climbing
+	}
+
+	return p
 }
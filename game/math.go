@@ -2,6 +2,7 @@ package game
 
 import (
 	"image/color"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -38,6 +39,15 @@ func (r Rect) Overlaps(box Rect) bool {
 	return r.Left < box.Right && r.Right > box.Left && r.Top < box.Bottom && r.Bottom >= box.Top
 }
 
+func (r Rect) Union(other Rect) Rect {
+	return Rect{
+		Left:   math.Min(r.Left, other.Left),
+		Top:    math.Min(r.Top, other.Top),
+		Right:  math.Max(r.Right, other.Right),
+		Bottom: math.Max(r.Bottom, other.Bottom),
+	}
+}
+
 func (r Rect) Scale(v float64) Rect {
 	return Rect{
 		Left:   r.Left * v,
@@ -0,0 +1,288 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+const (
+	// doorReach is how close the player holding the key has to come to a door to open it.
+	doorReach = 2.0
+	// switchHeight is the part of the cell at its bottom that has to be stood on to press a switch.
+	switchHeight = 10.0
+)
+
+// Link is the state shared by the keys, doors, switches, levers and blocks a level links by the same name.
+type Link struct {
+	name    string
+	key     bool
+	toggled bool
+	pressed int
+}
+
+// Active reports whether the blocks of the link are flipped from how the level places them, every lever flips them
+// and holding down any switch flips them once more.
+func (l *Link) Active() bool {
+	return l.toggled != (l.pressed > 0)
+}
+
+// HasKey reports whether the player picked up the key of the link.
+func (l *Link) HasKey() bool {
+	return l.key
+}
+
+// =====================================================================================================================
+
+// Key is picked up by touching it, after that the doors of its link open for the player.
+type Key struct {
+	*RectObject
+	link   *Link
+	player *Player
+}
+
+func NewKey(tex Drawable, area Rect, link *Link, player *Player) *Key {
+	return &Key{
+		RectObject: NewRectObject(tex, area),
+		link:       link,
+		player:     player,
+	}
+}
+
+func (k *Key) Draw(dst *Canvas) {
+	if k.link.key {
+		return
+	}
+	k.RectObject.Draw(dst)
+}
+
+func (k *Key) Step(dt float64) {
+	if k.link.key {
+		return
+	}
+	k.RectObject.Step(dt)
+
+	if !k.player.Dead() && k.player.Hitbox().Overlaps(k.area) {
+		k.link.key = true
+		k.player.Cheer()
+	}
+}
+
+// Door is a wall until the player holding the key of its link walks up to it, then it stays open.
+type Door struct {
+	*Ground
+	closedTex Drawable
+	openTex   Drawable
+	link      *Link
+	player    *Player
+	open      bool
+}
+
+func NewDoor(closedTex, openTex Drawable, area Rect, link *Link, player *Player) *Door {
+	return &Door{
+		Ground:    &Ground{RectObject: NewRectObject(closedTex, area), solid: true},
+		closedTex: closedTex,
+		openTex:   openTex,
+		link:      link,
+		player:    player,
+	}
+}
+
+func (d *Door) Step(dt float64) {
+	d.RectObject.Step(dt)
+	if d.open || !d.link.key {
+		return
+	}
+	if d.player.Hitbox().withPadding(-doorReach, 0).Overlaps(d.area) {
+		d.Open()
+	}
+}
+
+func (d *Door) Open() {
+	d.open = true
+	d.solid = false
+	d.texture = d.openTex
+}
+
+func (d *Door) Opened() bool {
+	return d.open
+}
+
+// Switch flips the blocks of its link. A pressure switch holds them flipped while the player or an npc stands on
+// it, a lever flips them for good every time the player walks into it.
+type Switch struct {
+	*RectObject
+	lever    bool
+	offTex   Drawable
+	onTex    Drawable
+	hitbox   Rect
+	link     *Link
+	player   *Player
+	npcs     []*Npc
+	touching bool
+	pressed  bool
+}
+
+func NewSwitch(lever bool, offTex, onTex Drawable, area Rect, link *Link, player *Player) *Switch {
+	hitbox := area
+	if !lever {
+		hitbox.Top = hitbox.Bottom - switchHeight*area.height()/50
+	}
+
+	return &Switch{
+		RectObject: NewRectObject(offTex, area),
+		lever:      lever,
+		offTex:     offTex,
+		onTex:      onTex,
+		hitbox:     hitbox,
+		link:       link,
+		player:     player,
+	}
+}
+
+func (s *Switch) Step(dt float64) {
+	s.RectObject.Step(dt)
+
+	touching := !s.player.Dead() && s.player.Hitbox().Overlaps(s.hitbox)
+	if s.lever {
+		if touching && !s.touching {
+			s.link.toggled = !s.link.toggled
+		}
+		s.touching = touching
+		s.setTexture(s.link.toggled)
+		return
+	}
+
+	for _, npc := range s.npcs {
+		touching = touching || npc.BoundingBox().Overlaps(s.hitbox)
+	}
+	if touching != s.pressed {
+		s.pressed = touching
+		if touching {
+			s.link.pressed++
+		} else {
+			s.link.pressed--
+		}
+	}
+	s.setTexture(s.pressed)
+}
+
+func (s *Switch) setTexture(on bool) {
+	if on {
+		s.texture = s.onTex
+	} else {
+		s.texture = s.offTex
+	}
+}
+
+// Block is a solid tile that its link switches on and off, an off block is neither drawn nor collided with.
+type Block struct {
+	*Ground
+	link *Link
+	on   bool
+}
+
+func NewBlock(tex Drawable, area Rect, link *Link, on bool) *Block {
+	return &Block{
+		Ground: &Ground{RectObject: NewRectObject(tex, area), solid: on},
+		link:   link,
+		on:     on,
+	}
+}
+
+func (b *Block) Draw(dst *Canvas) {
+	if !b.solid {
+		return
+	}
+	b.RectObject.Draw(dst)
+}
+
+func (b *Block) Step(dt float64) {
+	b.RectObject.Step(dt)
+	b.solid = b.on != b.link.Active()
+}
+
+// =====================================================================================================================
+
+// MechanismState is what the player changed about the linked objects of a level: the links whose key was picked up,
+// the doors opened, in reading order, and the links flipped by levers.
+type MechanismState struct {
+	Keys    []string `json:"keys"`
+	Doors   []int    `json:"doors"`
+	Toggled []string `json:"toggled"`
+}
+
+func (l *Level) link(name string) *Link {
+	if l.links == nil {
+		l.links = map[string]*Link{}
+	}
+	link, ok := l.links[name]
+	if !ok {
+		link = &Link{name: name}
+		l.links[name] = link
+	}
+	return link
+}
+
+func (l *Level) addMechanism(env LevelEnv, o levelmap.Object, cell Rect) {
+	link := l.link(o.Props.Link)
+	switch o.Type {
+	case levelmap.ObjectKey:
+		l.keys = append(l.keys, NewKey(env.Assets.Texture(KeyT), cell, link, nil))
+	case levelmap.ObjectDoor:
+		l.doors = append(l.doors, NewDoor(env.Assets.Texture(DoorT), env.Assets.Texture(DoorOpenT), cell, link, nil))
+	case levelmap.ObjectSwitch:
+		l.switches = append(l.switches, NewSwitch(false, env.Assets.Texture(SwitchT), env.Assets.Texture(SwitchPressedT), cell, link, nil))
+	case levelmap.ObjectLever:
+		l.switches = append(l.switches, NewSwitch(true, env.Assets.Texture(LeverOffT), env.Assets.Texture(LeverOnT), cell, link, nil))
+	case levelmap.ObjectBlock:
+		l.blocks = append(l.blocks, NewBlock(env.Assets.Texture(BlockT), cell, link, !o.Props.Off))
+	}
+}
+
+// Mechanisms returns the state of the linked objects to be saved.
+func (l *Level) Mechanisms() MechanismState {
+	var state MechanismState
+	for name, link := range l.links {
+		if link.key {
+			state.Keys = append(state.Keys, name)
+		}
+		if link.toggled {
+			state.Toggled = append(state.Toggled, name)
+		}
+	}
+	sort.Strings(state.Keys)
+	sort.Strings(state.Toggled)
+	for i, d := range l.doors {
+		if d.Opened() {
+			state.Doors = append(state.Doors, i)
+		}
+	}
+	return state
+}
+
+// RestoreMechanisms puts back a saved state, the blocks follow their links on the next step.
+func (l *Level) RestoreMechanisms(state MechanismState) error {
+	for _, name := range state.Keys {
+		link, ok := l.links[name]
+		if !ok {
+			return fmt.Errorf("level has no link %q", name)
+		}
+		link.key = true
+	}
+	for _, name := range state.Toggled {
+		link, ok := l.links[name]
+		if !ok {
+			return fmt.Errorf("level has no link %q", name)
+		}
+		link.toggled = true
+	}
+	for _, idx := range state.Doors {
+		if idx < 0 || idx >= len(l.doors) {
+			return fmt.Errorf("level has no door %d", idx)
+		}
+		l.doors[idx].Open()
+	}
+	return nil
+}
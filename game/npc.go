@@ -1,8 +1,15 @@
 package game
 
+// Defaults for the npcs a level places without properties.
+const (
+	NpcSpeed        = 2.0
+	NpcStunDuration = 0.25
+	NpcPushPower    = 10.0
+)
+
 type Npc struct {
 	*RectObject
-	grounds        []*Ground
+	grid           *GroundGrid
 	player         *Player
 	scale          float64
 	snapped        bool
@@ -11,6 +18,7 @@ type Npc struct {
 	stunDuration   float64
 	pushPower      float64
 	min, max       float64
+	patrol         bool
 	turnOffDrawing bool
 	walkLeft       bool
 	target         bool
@@ -36,12 +44,16 @@ func NewNpc(tex Drawable, area Rect, scale float64, speed float64, stunDuration
 	return n
 }
 
-func (n *Npc) Layout(sw, sh float64) {
-	n.RectObject.Layout(sw, sh)
+func (n *Npc) Step(dt float64) {
+	n.RectObject.Step(dt)
+	ticks := dt * TickRate
 
 	npcBox := n.BoundingBox()
-	for _, ground := range n.grounds {
-		gBox := ground.BoundingBox()
+	for _, ground := range n.grid.Query(npcBox.withPadding(0, n.scale*20)) {
+		if !ground.Solid() {
+			continue
+		}
+		gBox := n.surface(ground, npcBox)
 		topRegion := gBox.TopRegion(n.scale * 20)
 		if topRegion.Overlaps(npcBox) {
 			delta := npcBox.Bottom - gBox.Top
@@ -71,7 +83,7 @@ func (n *Npc) Layout(sw, sh float64) {
 	}
 
 	n.mirrorYaxis = !n.walkLeft
-	n.area = n.area.Offset(n.velocity.x, n.velocity.y)
+	n.area = n.area.Offset(n.velocity.x*ticks, n.velocity.y*ticks)
 }
 
 func (n *Npc) Draw(dst *Canvas) {
@@ -96,13 +108,15 @@ func (n *Npc) BoundingBox() Rect {
 	return box
 }
 
-func (n *Npc) RegisterGround(ground *Ground) {
-	n.grounds = append(n.grounds, ground)
+func (n *Npc) SetGrid(grid *GroundGrid) {
+	n.grid = grid
 }
 
 func (n *Npc) PushPLayer(p *Player) {
+	p.Damage(1, DamageNpc)
 
 	p.stun = n.stunDuration
+	p.climbing = false
 	p.velocity = Vec{
 		x: -p.velocity.x * 2,
 		y: -n.pushPower * n.scale,
@@ -116,10 +130,15 @@ func (n *Npc) register(player *Player) {
 func (n *Npc) TouchedGround(npcBox Rect) *Ground {
 	delta := n.BoundingBox().width() / 2.5
 
-	for _, ground := range n.grounds {
-		gBox := ground.BoundingBox()
+	for _, ground := range n.grid.Query(npcBox.withPadding(-delta, n.scale*20)) {
+		// open doors and blocks switched off are neither floor nor wall
+		if !ground.Solid() {
+			continue
+		}
+		gBox := n.surface(ground, npcBox)
 
-		if gBox.LeftRegion(delta).Overlaps(npcBox) || gBox.RightRegion(delta).Overlaps(npcBox) {
+		// one-way grounds and slopes are floor only, the npc walks off or up them instead of bumping into them
+		if !ground.OneWay() && !ground.Sloped() && (gBox.LeftRegion(delta).Overlaps(npcBox) || gBox.RightRegion(delta).Overlaps(npcBox)) {
 			return nil
 		}
 
@@ -131,10 +150,29 @@ func (n *Npc) TouchedGround(npcBox Rect) *Ground {
 	return nil
 }
 
+// surface is the box of the ground with its top moved to the surface under npcBox, so the npc follows slopes.
+func (n *Npc) surface(ground *Ground, npcBox Rect) Rect {
+	gBox := ground.BoundingBox()
+	gBox.Top = ground.SurfaceAt(npcBox)
+	return gBox
+}
+
 func (n *Npc) CanLeft() bool {
+	if n.patrol && n.area.Left-n.speed < n.min {
+		return false
+	}
 	return n.TouchedGround(n.BoundingBox().Offset(-n.speed, 0)) != nil
 }
 
 func (n *Npc) CanRight() bool {
+	if n.patrol && n.area.Right+n.speed > n.max {
+		return false
+	}
 	return n.TouchedGround(n.BoundingBox().Offset(n.speed, 0)) != nil
 }
+
+// SetPatrol keeps the npc between min and max on top of turning where the ground ends.
+func (n *Npc) SetPatrol(min, max float64) {
+	n.min, n.max = min, max
+	n.patrol = true
+}
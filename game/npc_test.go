@@ -0,0 +1,38 @@
+package game
+
+import (
+	"math"
+	"testing"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+// npcRange runs the level and returns the leftmost and rightmost edge its only npc reached.
+func npcRange(t *testing.T, level string, ticks int) (left, right float64) {
+	t.Helper()
+	h, err := NewHarness(level, 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right = math.Inf(1), math.Inf(-1)
+	for i := 0; i < ticks; i++ {
+		h.Step()
+		box := h.NpcPositions()[0]
+		left, right = math.Min(left, box.Left), math.Max(right, box.Right)
+	}
+	return left, right
+}
+
+func TestNpcIgnoresBlocksSwitchedOff(t *testing.T) {
+	// the npc turns where the floor ends, a block switched off is not floor
+	_, right := npcRange(t, "X          X\nX R      PGX\nXXXXBBXXXXXX\nXXXXXXXXXXXX\n\nB block bridge off", 300)
+	if right > levelmap.Cell(5, 0).Left {
+		t.Errorf("npc walked over blocks switched off, reached %v", right)
+	}
+
+	// and it walks through one in its way instead of turning at it
+	_, right = npcRange(t, "X           X\nX R B     PGX\nXXXXXXXXXXXXX\n\nB block wall off", 300)
+	if right < levelmap.Cell(6, 0).Left {
+		t.Errorf("npc turned at a block switched off, reached %v", right)
+	}
+}
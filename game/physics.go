@@ -0,0 +1,17 @@
+package game
+
+import (
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/physics"
+)
+
+// PlayerPhysics tunes how the player moves, it lives in the physics package so levelcheck shares it.
+type PlayerPhysics = physics.Player
+
+func DefaultPlayerPhysics() PlayerPhysics {
+	return physics.DefaultPlayer()
+}
+
+// LoadPlayerPhysics reads a profile, fields missing in the file keep their default value.
+func LoadPlayerPhysics(filename string) (PlayerPhysics, error) {
+	return physics.LoadPlayer(filename)
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"math"
+
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
+)
+
+const (
+	// platformSpeed is how far a moving platform travels per tick, in unscaled pixels.
+	platformSpeed = 2.0
+	// platformHeight is the thickness of a moving platform in unscaled pixels, it sits at the top of its cell.
+	platformHeight = levelmap.ThinHeight
+)
+
+// MovingPlatform is a Ground that travels through its path and starts over from the first point after the last.
+// It moves before the player and the npcs, whoever stands on it is moved by the same delta.
+type MovingPlatform struct {
+	*Ground
+	grid   *GroundGrid
+	cell   float64
+	path   []Vec
+	target int
+	speed  float64
+	delta  Vec
+	player *Player
+	npcs   []*Npc
+}
+
+// NewMovingPlatform takes the area and the path of its top left corner in unscaled level coordinates.
+func NewMovingPlatform(tex Drawable, area Rect, path []Vec, oneWay bool, scale float64) *MovingPlatform {
+	p := &MovingPlatform{
+		Ground: NewGround(tex, area, true, scale),
+		cell:   50 * scale,
+		speed:  platformSpeed * scale,
+	}
+	p.SetOneWay(oneWay)
+	for _, point := range path {
+		p.path = append(p.path, Vec{x: point.x * scale, y: point.y * scale})
+	}
+	return p
+}
+
+// Draw repeats the texture for every cell of the platform instead of stretching it.
+func (p *MovingPlatform) Draw(dst *Canvas) {
+	full := p.area
+	for left := full.Left; left < full.Right-touchEpsilon; left += p.cell {
+		p.area = Rect{Left: left, Top: full.Top, Right: math.Min(left+p.cell, full.Right), Bottom: full.Bottom}
+		p.Ground.Draw(dst)
+	}
+	p.area = full
+}
+
+func (p *MovingPlatform) Step(dt float64) {
+	p.delta = Vec{}
+	if len(p.path) == 0 {
+		return
+	}
+
+	target := p.path[p.target]
+	dx, dy := target.x-p.area.Left, target.y-p.area.Top
+	dist := math.Hypot(dx, dy)
+	step := p.speed * dt * TickRate
+	if dist <= step {
+		p.target = (p.target + 1) % len(p.path)
+	} else {
+		dx, dy = dx/dist*step, dy/dist*step
+	}
+	p.Move(dx, dy)
+}
+
+// Move moves the platform and everything standing on it, the grid is updated so collisions see the new position.
+func (p *MovingPlatform) Move(dx, dy float64) {
+	box := p.BoundingBox()
+	carryPlayer := p.player != nil && p.player.Grounded() && p.carries(p.player.Hitbox(), box)
+	var carryNpcs []*Npc
+	for _, npc := range p.npcs {
+		if p.carries(npc.BoundingBox(), box) {
+			carryNpcs = append(carryNpcs, npc)
+		}
+	}
+
+	if p.grid != nil {
+		p.grid.Remove(p.Ground)
+	}
+	p.area = p.area.Offset(dx, dy)
+	if p.grid != nil {
+		p.grid.Insert(p.Ground)
+	}
+	p.delta = Vec{x: dx, y: dy}
+
+	if carryPlayer {
+		p.player.Move(dx, dy)
+	}
+	for _, npc := range carryNpcs {
+		npc.area = npc.area.Offset(dx, dy)
+	}
+}
+
+// carries reports whether box stands on top of the platform box.
+func (p *MovingPlatform) carries(box, platform Rect) bool {
+	return overlapsHorizontally(box, platform) && abs(box.Bottom-platform.Top) <= touchEpsilon
+}
+
+func (p *MovingPlatform) SetGrid(grid *GroundGrid) {
+	p.grid = grid
+}
+
+// Delta is how far the platform moved during the last step.
+func (p *MovingPlatform) Delta() (dx, dy float64) {
+	return p.delta.x, p.delta.y
+}
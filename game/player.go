@@ -1,22 +1,37 @@
 package game
 
-import (
-	"math"
+import "math"
 
-	"github.com/hajimehoshi/ebiten/v2"
-)
+// dropTime is how long the player ignores one-way grounds after dropping through one.
+const dropTime = 0.25
 
 type Player struct {
 	*RectObject
 	scale        float64
+	input        *Input
+	physics      PlayerPhysics
+	coyote       float64
+	jumpBuffer   float64
+	dropping     float64
+	climbing     bool
+	jumpLock     bool
+	ladders      []Rect
 	stun         float64
 	boringTimer  float64
-	grounds      []*Ground
+	grid         *GroundGrid
 	npcs         []*Npc
 	velocity     Vec
 	jumpDuration int
 	jumping      bool
-	snapped      bool
+	jumpBuffered bool
+	contacts     Contact
+	health       int
+	lives        int
+	invulnerable float64
+	hurt         float64
+	cheer        float64
+	lastDamage   DamageSource
+	respawn      Vec
 	lookingDirR  bool
 	lookRight    bool
 	onMovement   func()
@@ -24,31 +39,58 @@ type Player struct {
 	onJump       func()
 	onFall       func()
 	onBoring     func()
+	onHurt       func()
+	onCheer      func()
+	onClimb      func()
+	onGameOver   func()
+	gameOver     bool
 }
 
-func NewPlayer(tex Drawable, area Rect, scale float64) *Player {
+func NewPlayer(tex Drawable, area Rect, scale float64, input *Input) *Player {
 	area.Top = area.Top - 50
 	area.Left = area.Left - 50
 	p := &Player{
 		RectObject:  NewRectObject(tex, area),
 		scale:       scale,
+		input:       input,
+		physics:     DefaultPlayerPhysics(),
+		health:      PlayerMaxHealth,
+		lives:       PlayerLives,
 		lookRight:   false,
 		lookingDirR: false,
 	}
+	box := p.Hitbox()
+	p.SetRespawn(box.Left, box.Bottom)
 
 	return p
 }
 
-func (p *Player) Layout(sw, sh float64) {
-	p.RectObject.Layout(sw, sh)
-	p.stun--
-	p.Movement()
+// Step advances the player by dt seconds. Velocities are kept in pixels per tick of TickRate.
+func (p *Player) Step(dt float64) {
+	if p.Dead() {
+		// the game is over once, the dead player keeps stepping until the scene changes
+		if !p.gameOver && p.onGameOver != nil {
+			p.onGameOver()
+		}
+		p.gameOver = true
+		return
+	}
 
-	playerBox := p.BoundingBox()
-	if p.lookingDirR {
-		playerBox = playerBox.ChangeX(0)
+	p.RectObject.Step(dt)
+	p.stun -= dt
+	p.boringTimer += dt
+	p.invulnerable -= dt
+	p.hurt -= dt
+	p.cheer -= dt
+	p.Movement(dt)
+	if p.hurt > 0 && p.onHurt != nil {
+		p.onHurt()
+	} else if p.cheer > 0 && p.onCheer != nil {
+		p.onCheer()
 	}
 
+	playerBox := p.Hitbox()
+
 	//npc interactions
 	for _, enemy := range p.npcs {
 		if playerBox.Overlaps(enemy.BoundingBox()) {
@@ -58,12 +100,39 @@ func (p *Player) Layout(sw, sh float64) {
 
 }
 
-func (p *Player) Movement() {
+func (p *Player) Movement(dt float64) {
 	scale := p.scale
+	ticks := dt * TickRate
+	phys := p.physics
+
+	if p.Grounded() {
+		p.coyote = phys.CoyoteTime
+	} else {
+		p.coyote -= dt
+	}
+	if p.input.JustPressed(ActionJump) {
+		p.jumpBuffer = phys.JumpBuffer
+	} else {
+		p.jumpBuffer -= dt
+	}
+	p.dropping -= dt
+	if !p.input.Pressed(ActionJump) {
+		p.jumpLock = false
+	}
+
+	if p.stun < 0 && !p.climbing {
+		p.startClimb()
+	}
+	if p.climbing {
+		p.climb(dt)
+		return
+	}
+
 	//inputs
 	if p.stun < 0 {
-		if ebiten.IsKeyPressed(ebiten.KeyRight) {
-			p.velocity.x = 8 * scale
+		target := 0.0
+		if p.input.Pressed(ActionMoveRight) {
+			target = phys.Speed * scale
 			if p.lookRight == false {
 				p.lookRight = true
 			}
@@ -71,8 +140,8 @@ func (p *Player) Movement() {
 			if p.onMovement != nil {
 				p.onMovement()
 			}
-		} else if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-			p.velocity.x = -8 * scale
+		} else if p.input.Pressed(ActionMoveLeft) {
+			target = -phys.Speed * scale
 			if p.lookRight == true {
 				p.lookRight = false
 			}
@@ -81,10 +150,9 @@ func (p *Player) Movement() {
 				p.onMovement()
 			}
 		} else {
-			p.velocity.x = 0
 			if p.onIdle != nil && !p.jumping {
 				p.onIdle()
-				if p.boringTimer > 60*7 {
+				if p.boringTimer > 7 {
 					if p.onBoring != nil {
 						p.onBoring()
 					}
@@ -92,91 +160,123 @@ func (p *Player) Movement() {
 			}
 		}
 
+		if p.Grounded() || phys.AirControl >= 1 {
+			p.velocity.x = target
+		} else {
+			p.velocity.x += (target - p.velocity.x) * math.Min(1, phys.AirControl*ticks)
+		}
+
+		if p.input.Pressed(ActionMoveDown) && !p.jumping && p.Grounded() && p.OnOneWay() {
+			p.Action()
+			p.dropping = dropTime
+			p.contacts &^= ContactFloor
+			p.coyote = 0
+		}
+
 		if p.jumping {
 			p.Action()
 			p.jumpDuration++
-			if ebiten.IsKeyPressed(ebiten.KeyUp) {
-				p.velocity.y -= 2 * scale
+			if p.input.Pressed(ActionJump) || p.jumpBuffered {
+				p.velocity.y -= phys.JumpImpulse * scale * ticks
+			} else {
+				p.jumping = false
+				if p.velocity.y < 0 {
+					p.velocity.y *= phys.JumpCutoff
+				}
 			}
-			if p.jumpDuration > 5 {
+			if p.jumpDuration > phys.JumpTicks {
 				p.jumping = false
 			}
 		} else {
-			if p.Grounded() {
+			held := p.input.Pressed(ActionJump) && !p.jumpLock
+			if (held || p.jumpBuffer > 0) && (p.Grounded() || p.coyote > 0) {
+				p.startJump(!held, ticks)
+			} else if p.Grounded() {
 				p.velocity.y = 0
-				if ebiten.IsKeyPressed(ebiten.KeyUp) {
-					p.jumping = true
-					p.jumpDuration = 0
-				}
 			} else {
-				if p.velocity.y < 20*scale {
-					p.velocity.y += 1 * scale
+				if p.velocity.y < phys.TerminalVelocity*scale {
+					p.velocity.y += phys.Gravity * scale * ticks
 				}
 			}
 		}
 		if !p.lookingDirR && p.lookRight || p.lookingDirR && !p.lookRight {
-			p.area = p.area.ChangeX(p.BoundingBox().width())
-			p.lookingDirR = !p.lookingDirR
+			p.turn()
 		}
 	}
-	p.area = p.area.Offset(p.velocity.x, p.velocity.y)
+	p.Move(p.velocity.x*ticks, p.velocity.y*ticks)
 
 	if !p.Grounded() && p.velocity.y < 1 {
 		if p.onJump != nil {
 			p.onJump()
 		}
 	}
-
-	p.snapped = false
-	p.Snap()
 }
 
-func (p *Player) Snap() {
-
-	scale := p.scale
+// startJump gives the jump its first impulse on the tick it starts. The jump keeps rising for JumpTicks while the
+// key is held, a buffered jump was pressed before landing and may be released already, it always rises fully.
+func (p *Player) startJump(buffered bool, ticks float64) {
+	p.jumping = true
+	p.jumpBuffered = buffered
+	p.jumpDuration = 1
+	p.velocity.y = -p.physics.JumpImpulse * p.scale * ticks
+	p.coyote = 0
+	p.jumpBuffer = 0
+}
 
-	playerBox := p.BoundingBox()
+// turn mirrors the sprite while keeping the hitbox in place, so turning around next to a wall cannot push the
+// player into it.
+func (p *Player) turn() {
+	before := p.Hitbox()
+	p.area = p.area.ChangeX(p.BoundingBox().width())
+	p.lookingDirR = !p.lookingDirR
+	after := p.Hitbox()
+	p.area = p.area.Offset(before.Left-after.Left, before.Top-after.Top)
+}
 
-	if p.lookingDirR {
-		playerBox = playerBox.ChangeX(0)
-	}
-	for _, ground := range p.grounds {
-		if !p.snapped {
-			groundBox := ground.BoundingBox()
-			if playerBox.Overlaps(groundBox) {
-				if math.Abs(groundBox.Top-playerBox.Bottom) < 20*scale {
-					delta := playerBox.Bottom - groundBox.Top
-					p.area = p.area.Offset(0, -delta)
-					p.snapped = true
-				} else if ground.Solid() {
-					bottomHalf := groundBox
-					bottomHalf.Left = groundBox.Left + 10*scale
-					bottomHalf.Right = groundBox.Right - 10*scale
-					bottomHalf.Top = groundBox.Bottom + groundBox.height()/2
-					if playerBox.Overlaps(bottomHalf) {
-						delta := playerBox.Top - groundBox.Bottom
-						p.area = p.area.Offset(0, -delta)
-						p.velocity.y += 3 * scale
-					} else {
-						leftHalf := groundBox
-						leftHalf.Right = groundBox.Left + groundBox.width()/2
-						if playerBox.Overlaps(leftHalf) {
-							delta := groundBox.Left - playerBox.Right
-							p.area = p.area.Offset(delta, 0)
-							p.snapped = true
-						} else {
-							delta := groundBox.Right - playerBox.Left
-							p.area = p.area.Offset(delta, 0)
-							p.snapped = true
-						}
-					}
-				}
+// Move moves the player through the level with MoveAndCollide and stops the velocity on the sides it hit. A player
+// walking on the ground is kept on it when the floor drops by no more than the distance walked, so walking down a
+// slope does not turn into a series of small falls.
+func (p *Player) Move(dx, dy float64) {
+	grounded := p.Grounded() && !p.jumping && dy >= 0
+	box := p.Hitbox()
+	reach := box.Union(box.Offset(dx, dy+abs(dx))).withPadding(-1, 1)
+	grounds := p.grid.Query(reach)
+	if p.dropping > 0 || p.climbing {
+		// filtering in place is fine, the query result is scratch space until the next query
+		solid := grounds[:0]
+		for _, g := range grounds {
+			if !g.OneWay() {
+				solid = append(solid, g)
 			}
 		}
+		grounds = solid
+	}
+	moved, contacts := MoveAndCollide(box, dx, dy, grounds)
+	if grounded && !contacts.Has(ContactFloor) {
+		if snapped, snapContacts := MoveAndCollide(moved, 0, abs(dx)+touchEpsilon, grounds); snapContacts.Has(ContactFloor) {
+			moved, contacts = snapped, contacts|snapContacts
+		}
+	}
+	p.area = p.area.Offset(moved.Left-box.Left, moved.Top-box.Top)
+	p.contacts = contacts
+
+	if contacts.Has(ContactFloor) && p.velocity.y > 0 {
+		p.velocity.y = 0
+	}
+	if contacts.Has(ContactCeiling) && p.velocity.y < 0 {
+		p.velocity.y = 0
+		p.jumping = false
+	}
+	if contacts.Has(ContactLeftWall) && p.velocity.x < 0 || contacts.Has(ContactRightWall) && p.velocity.x > 0 {
+		p.velocity.x = 0
 	}
 }
 
 func (p *Player) Draw(dst *Canvas) {
+	// blink while invulnerable
+	if p.Invulnerable() && int(p.invulnerable*10)%2 == 0 {
+		return
+	}
 
 	p.RectObject.Draw(dst)
 
@@ -203,31 +303,52 @@ func (p *Player) BoundingBox() Rect {
 	return playerBox
 }
 
-func (p *Player) AppendGround(ground *Ground) {
-	p.grounds = append(p.grounds, ground)
+// Hitbox is BoundingBox with Left and Right in order, BoundingBox swaps them while the sprite is mirrored.
+func (p *Player) Hitbox() Rect {
+	box := p.BoundingBox()
+	if p.lookingDirR {
+		box = box.ChangeX(0)
+	}
+	return box
 }
 
-func (p *Player) Grounded() bool {
-	if p.velocity.y < 0 {
-		return false
-	}
+func (p *Player) SetPhysics(physics PlayerPhysics) {
+	p.physics = physics
+}
 
-	scale := p.scale
+func (p *Player) SetGrid(grid *GroundGrid) {
+	p.grid = grid
+}
 
-	playerBox := p.BoundingBox()
-	if p.lookingDirR {
-		playerBox = playerBox.ChangeX(0)
-	}
-	for _, ground := range p.grounds {
-		groundBox := ground.BoundingBox()
-		if playerBox.Overlaps(groundBox) {
-			if math.Abs(groundBox.Top-playerBox.Bottom) < 20*scale {
-				return true
-			}
+// Grounded reports whether the last move ended on a floor, a player moving up is never grounded.
+func (p *Player) Grounded() bool {
+	return p.velocity.y >= 0 && p.contacts.Has(ContactFloor)
+}
+
+// OnOneWay reports whether every floor under the player is one-way, so pressing Down can drop through it.
+func (p *Player) OnOneWay() bool {
+	box := p.Hitbox()
+	found := false
+	for _, g := range p.grid.Query(box.withPadding(0, 1)) {
+		gBox := g.BoundingBox()
+		if !g.Solid() || !overlapsHorizontally(box, gBox) || abs(gBox.Top-box.Bottom) > touchEpsilon {
+			continue
+		}
+		if !g.OneWay() {
+			return false
 		}
+		found = true
 	}
+	return found
+}
+
+func (p *Player) Contacts() Contact {
+	return p.contacts
+}
 
-	return false
+// Cheer plays the JoyFul animation for a moment, it gives way to the hurt animation.
+func (p *Player) Cheer() {
+	p.cheer = cheerTime
 }
 
 func (p *Player) Action() {
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"testing"
+)
+
+const jumpLevel = `X   P   X
+X       X
+X       X
+X       X
+X       X
+X       X
+X       X
+X       X
+X      GX
+XXXXXXXXX`
+
+func jumpHarness(t *testing.T, script InputScript) *Harness {
+	t.Helper()
+	h, err := NewHarness(jumpLevel, 1, 1, script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// landingTick is the tick the player spawned in the air first stands on the floor without input.
+func landingTick(t *testing.T) int {
+	t.Helper()
+	h := jumpHarness(t, nil)
+	if !h.RunUntil(200, (*Harness).Grounded) {
+		t.Fatal("player never landed")
+	}
+	return h.Tick()
+}
+
+// jumpHeight runs the script from the spawn and returns how far the player rose above the floor.
+func jumpHeight(t *testing.T, script InputScript) float64 {
+	t.Helper()
+	h := jumpHarness(t, script)
+	h.RunUntil(200, (*Harness).Grounded)
+	floor := h.PlayerBox().Bottom
+	highest := floor
+	for i := 0; i < 120; i++ {
+		h.Step()
+		highest = min(highest, h.PlayerBox().Bottom)
+	}
+	return floor - highest
+}
+
+func TestJumpHeights(t *testing.T) {
+	land := landingTick(t)
+	idle := InputScript{}.Hold(land + 10)
+
+	held := jumpHeight(t, idle.Hold(60, ActionJump).Hold(1))
+	tapped := jumpHeight(t, idle.Hold(1, ActionJump).Hold(1))
+	buffered := jumpHeight(t, InputScript{}.Hold(land-3).Hold(1, ActionJump).Hold(1))
+
+	if tapped <= 0 {
+		t.Errorf("a tapped jump left the player on the floor")
+	}
+	if tapped >= held {
+		t.Errorf("a tapped jump rose %v, as high as a held one %v", tapped, held)
+	}
+	if buffered != held {
+		t.Errorf("a buffered jump rose %v, a held one %v", buffered, held)
+	}
+}
+
+func TestLadderJump(t *testing.T) {
+	climb := InputScript{}.Hold(20, ActionMoveUp)
+	h, err := NewHarness("X    X\nX  H X\nX  H X\nX  H X\nX  H X\nXG P X\nXXXXXX", 1, 1,
+		climb.Hold(1, ActionJump).Hold(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Run(len(climb))
+	if !h.Player().Climbing() {
+		t.Fatal("player is not on the ladder")
+	}
+	start := h.PlayerBox().Bottom
+	highest := start
+	for i := 0; i < 30; i++ {
+		h.Step()
+		highest = min(highest, h.PlayerBox().Bottom)
+	}
+	if h.Player().Climbing() {
+		t.Fatal("jump did not let go of the ladder")
+	}
+	if highest >= start {
+		t.Errorf("jumping off the ladder gave no height")
+	}
+}
@@ -105,5 +105,8 @@ func (r *RectObject) BoundingBox() Rect {
 }
 
 func (r *RectObject) Layout(sw, sh float64) {
-	r.texture.Update()
+}
+
+func (r *RectObject) Step(dt float64) {
+	r.texture.Update(dt)
 }
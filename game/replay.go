@@ -0,0 +1,200 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	replayMagic = "AITW"
+	// replayVersion 2 added the start state, version 1 files are still read
+	replayVersion = 2
+	// maxReplayStart bounds the json of the start state a replay file can make ReadReplay allocate
+	maxReplayStart = 1 << 20
+	// maxReplayTicks bounds the ticks the run lengths of a replay file expand to, six hours of play
+	maxReplayTicks = 6 * 60 * 60 * TickRate
+)
+
+// Replay holds everything needed to reproduce a run: the level, the scale the physics ran at, the seed for
+// Level.Build, the player physics of the level, the saved run a continued game started from and the actions held on
+// every tick. Nil physics are the default player physics.
+type Replay struct {
+	Level     int
+	Scale     float64
+	Seed      int64
+	Physics   *PlayerPhysics
+	Continued *SavedRun
+	Ticks     []ActionSet
+}
+
+// replayStart is the state the level is put in before the first tick, stored as json after the header.
+type replayStart struct {
+	Physics   *PlayerPhysics `json:"physics,omitempty"`
+	Continued *SavedRun      `json:"continued,omitempty"`
+}
+
+func NewReplay(level int, scale float64, seed int64) *Replay {
+	return &Replay{Level: level, Scale: scale, Seed: seed}
+}
+
+func (r *Replay) Record(set ActionSet) {
+	r.Ticks = append(r.Ticks, set)
+}
+
+// At returns the actions of the given tick, nothing is held once the replay ran out.
+func (r *Replay) At(tick int) ActionSet {
+	if tick < 0 || tick >= len(r.Ticks) {
+		return 0
+	}
+	return r.Ticks[tick]
+}
+
+func (r *Replay) Script() InputScript {
+	return append(InputScript(nil), r.Ticks...).Hold(1)
+}
+
+// WriteTo stores the ticks run-length encoded, a held key is the same set for many ticks in a row.
+func (r *Replay) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString(replayMagic)
+	buf.WriteByte(replayVersion)
+	buf.Write(binary.AppendUvarint(nil, uint64(r.Level)))
+	buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(r.Scale)))
+	buf.Write(binary.AppendVarint(nil, r.Seed))
+	start, err := json.Marshal(replayStart{Physics: r.Physics, Continued: r.Continued})
+	if err != nil {
+		return 0, fmt.Errorf("cant encode replay start %w", err)
+	}
+	buf.Write(binary.AppendUvarint(nil, uint64(len(start))))
+	buf.Write(start)
+
+	var runs [][2]uint64
+	for i, set := range r.Ticks {
+		if i > 0 && runs[len(runs)-1][1] == uint64(set) {
+			runs[len(runs)-1][0]++
+			continue
+		}
+		runs = append(runs, [2]uint64{1, uint64(set)})
+	}
+	buf.Write(binary.AppendUvarint(nil, uint64(len(runs))))
+	for _, run := range runs {
+		buf.Write(binary.AppendUvarint(nil, run[0]))
+		buf.Write(binary.AppendUvarint(nil, run[1]))
+	}
+
+	n, err := w.Write(buf.Bytes())
+	if err != nil {
+		return int64(n), fmt.Errorf("cant write replay %w", err)
+	}
+	return int64(n), nil
+}
+
+func ReadReplay(in io.Reader) (*Replay, error) {
+	rd := bufio.NewReader(in)
+
+	magic := make([]byte, len(replayMagic)+1)
+	if _, err := io.ReadFull(rd, magic); err != nil {
+		return nil, fmt.Errorf("cant read replay header %w", err)
+	}
+	if string(magic[:len(replayMagic)]) != replayMagic {
+		return nil, fmt.Errorf("not a replay file")
+	}
+	version := magic[len(replayMagic)]
+	if version < 1 || version > replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", version)
+	}
+
+	level, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("cant read replay level %w", err)
+	}
+	var scaleBits uint64
+	if err := binary.Read(rd, binary.LittleEndian, &scaleBits); err != nil {
+		return nil, fmt.Errorf("cant read replay scale %w", err)
+	}
+	seed, err := binary.ReadVarint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("cant read replay seed %w", err)
+	}
+
+	r := NewReplay(int(level), math.Float64frombits(scaleBits), seed)
+	if version >= 2 {
+		size, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, fmt.Errorf("cant read replay start %w", err)
+		}
+		if size > maxReplayStart {
+			return nil, fmt.Errorf("replay start of %d bytes is too long", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, fmt.Errorf("cant read replay start %w", err)
+		}
+		var start replayStart
+		if err := json.Unmarshal(data, &start); err != nil {
+			return nil, fmt.Errorf("cant parse replay start %w", err)
+		}
+		r.Physics = start.Physics
+		r.Continued = start.Continued
+	}
+	runs, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("cant read replay length %w", err)
+	}
+	for i := uint64(0); i < runs; i++ {
+		length, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, fmt.Errorf("cant read replay run %d %w", i, err)
+		}
+		set, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, fmt.Errorf("cant read replay run %d %w", i, err)
+		}
+		if length > maxReplayTicks-uint64(len(r.Ticks)) {
+			return nil, fmt.Errorf("replay is longer than %d ticks", maxReplayTicks)
+		}
+		for j := uint64(0); j < length; j++ {
+			r.Record(ActionSet(set))
+		}
+	}
+
+	return r, nil
+}
+
+// RecordingPath is the file the recording of level lvl is saved to, the level number goes before the extension of
+// base so every level of a session keeps its own recording.
+func RecordingPath(base string, lvl int) string {
+	ext := filepath.Ext(base)
+	return fmt.Sprintf("%s-level%d%s", strings.TrimSuffix(base, ext), lvl, ext)
+}
+
+func (r *Replay) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("cant create replay file %w", err)
+	}
+	defer f.Close()
+
+	if _, err := r.WriteTo(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func LoadReplay(filename string) (*Replay, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cant open replay file %w", err)
+	}
+	defer f.Close()
+
+	return ReadReplay(f)
+}
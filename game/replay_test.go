@@ -0,0 +1,126 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRecordingPath(t *testing.T) {
+	tests := []struct {
+		base string
+		lvl  int
+		want string
+	}{
+		{"run.rpl", 1, "run-level1.rpl"},
+		{"runs/today.rpl", 12, "runs/today-level12.rpl"},
+		{"run", 2, "run-level2"},
+		{"runs.d/run", 3, "runs.d/run-level3"},
+	}
+	for _, test := range tests {
+		if got := RecordingPath(test.base, test.lvl); got != test.want {
+			t.Errorf("RecordingPath(%q, %d) = %q, want %q", test.base, test.lvl, got, test.want)
+		}
+	}
+}
+
+func TestReplayContinuedRun(t *testing.T) {
+	r := NewReplay(2, 1, 7)
+	// a save from before checkpoints, only the position of the player is restored
+	r.Continued = &SavedRun{Checkpoint: -1, Left: 500, Top: 100}
+	physics := DefaultPlayerPhysics()
+	physics.Speed = 3
+	r.Physics = &physics
+	r.Record(Actions(ActionMoveRight))
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, r) {
+		t.Fatalf("read %+v, wrote %+v", read, r)
+	}
+
+	h, err := NewReplayHarness(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := h.PlayerPosition(); x != 500 || y != 100 {
+		t.Errorf("replay started at %v %v, want the saved 500 100", x, y)
+	}
+}
+
+func TestReplayPhysics(t *testing.T) {
+	physics := DefaultPlayerPhysics()
+	physics.Speed = 3
+	r := NewReplay(1, 1, 1)
+	r.Physics = &physics
+	r.Ticks = InputScript{}.Hold(60).Hold(10, ActionMoveRight)
+
+	h, err := NewReplayHarness(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Run(60)
+	x := h.PlayerBox().Left
+	h.Run(10)
+	if nx := h.PlayerBox().Left; nx-x != 10*physics.Speed {
+		t.Errorf("replay walked %v in 10 ticks with speed %v", nx-x, physics.Speed)
+	}
+}
+
+func TestReadReplayVersion1(t *testing.T) {
+	data := []byte(replayMagic)
+	data = append(data, 1)
+	data = binary.AppendUvarint(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(1.5))
+	data = binary.AppendVarint(data, -3)
+	data = binary.AppendUvarint(data, 1)
+	data = binary.AppendUvarint(data, 2)
+	data = binary.AppendUvarint(data, uint64(Actions(ActionJump)))
+
+	r, err := ReadReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Replay{Level: 1, Scale: 1.5, Seed: -3, Ticks: []ActionSet{Actions(ActionJump), Actions(ActionJump)}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("read %+v, want %+v", r, want)
+	}
+}
+
+func TestReadReplayTooLong(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewReplay(1, 1, 1).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	header := buf.Bytes()[:buf.Len()-1]
+	withRuns := func(lengths ...uint64) []byte {
+		data := binary.AppendUvarint(append([]byte(nil), header...), uint64(len(lengths)))
+		for _, length := range lengths {
+			data = binary.AppendUvarint(data, length)
+			data = binary.AppendUvarint(data, 0)
+		}
+		return data
+	}
+
+	r, err := ReadReplay(bytes.NewReader(withRuns(maxReplayTicks-1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ticks) != maxReplayTicks {
+		t.Errorf("read %d ticks, want %d", len(r.Ticks), maxReplayTicks)
+	}
+	if _, err := ReadReplay(bytes.NewReader(withRuns(maxReplayTicks, 1))); err == nil {
+		t.Error("a replay over the tick limit was read")
+	}
+	if _, err := ReadReplay(bytes.NewReader(withRuns(math.MaxUint64))); err == nil {
+		t.Error("a replay with a huge run was read")
+	}
+}
@@ -0,0 +1,267 @@
+package game
+
+import "fmt"
+
+type Scene interface {
+	Enter()
+	Exit()
+	Update(dt, sw, sh float64) error
+	Draw(dst *Canvas)
+}
+
+// PausableScene is implemented by scenes that want to know when another scene is pushed on top of them.
+type PausableScene interface {
+	Pause()
+	Resume()
+}
+
+// BackHandler is implemented by scenes that react to the "back" key (Escape).
+type BackHandler interface {
+	Back()
+}
+
+// =====================================================================================================================
+
+type sceneOp struct {
+	kind sceneOpKind
+	name string
+}
+
+type sceneOpKind int
+
+const (
+	scenePush sceneOpKind = iota
+	scenePop
+	sceneReplace
+	sceneReset
+)
+
+type SceneManager struct {
+	factories map[string]func() Scene
+	stack     []Scene
+	names     []string
+	pending   []sceneOp
+}
+
+func NewSceneManager() *SceneManager {
+	return &SceneManager{factories: map[string]func() Scene{}}
+}
+
+func (m *SceneManager) Register(name string, factory func() Scene) {
+	m.factories[name] = factory
+}
+
+// Push, Pop, Replace and Reset are queued and applied on the next Flush, so they are safe to call from inside a
+// scene's Update.
+func (m *SceneManager) Push(name string) {
+	m.pending = append(m.pending, sceneOp{kind: scenePush, name: name})
+}
+
+func (m *SceneManager) Pop() {
+	m.pending = append(m.pending, sceneOp{kind: scenePop})
+}
+
+func (m *SceneManager) Replace(name string) {
+	m.pending = append(m.pending, sceneOp{kind: sceneReplace, name: name})
+}
+
+func (m *SceneManager) Reset(name string) {
+	m.pending = append(m.pending, sceneOp{kind: sceneReset, name: name})
+}
+
+func (m *SceneManager) Flush() error {
+	ops := m.pending
+	m.pending = nil
+	for _, op := range ops {
+		var err error
+		switch op.kind {
+		case scenePush:
+			err = m.push(op.name)
+		case scenePop:
+			m.pop()
+		case sceneReplace:
+			err = m.replace(op.name)
+		case sceneReset:
+			err = m.reset(op.name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close exits every scene right away, it is used when the game shuts down.
+func (m *SceneManager) Close() {
+	for i := len(m.stack) - 1; i >= 0; i-- {
+		m.stack[i].Exit()
+	}
+	m.stack = nil
+	m.names = nil
+	m.pending = nil
+}
+
+func (m *SceneManager) Current() Scene {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+func (m *SceneManager) CurrentName() string {
+	if len(m.names) == 0 {
+		return ""
+	}
+	return m.names[len(m.names)-1]
+}
+
+func (m *SceneManager) Depth() int {
+	return len(m.stack)
+}
+
+func (m *SceneManager) create(name string) (Scene, error) {
+	factory, ok := m.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("scene %q is not registered", name)
+	}
+	return factory(), nil
+}
+
+func (m *SceneManager) push(name string) error {
+	scene, err := m.create(name)
+	if err != nil {
+		return err
+	}
+	if p, ok := m.Current().(PausableScene); ok {
+		p.Pause()
+	}
+	m.stack = append(m.stack, scene)
+	m.names = append(m.names, name)
+	scene.Enter()
+	return nil
+}
+
+// pop never removes the root scene, there always has to be something to show.
+func (m *SceneManager) pop() {
+	if len(m.stack) <= 1 {
+		return
+	}
+	m.Current().Exit()
+	m.stack = m.stack[:len(m.stack)-1]
+	m.names = m.names[:len(m.names)-1]
+	if p, ok := m.Current().(PausableScene); ok {
+		p.Resume()
+	}
+}
+
+func (m *SceneManager) replace(name string) error {
+	scene, err := m.create(name)
+	if err != nil {
+		return err
+	}
+	if len(m.stack) > 0 {
+		m.Current().Exit()
+		m.stack = m.stack[:len(m.stack)-1]
+		m.names = m.names[:len(m.names)-1]
+	}
+	m.stack = append(m.stack, scene)
+	m.names = append(m.names, name)
+	scene.Enter()
+	return nil
+}
+
+func (m *SceneManager) reset(name string) error {
+	scene, err := m.create(name)
+	if err != nil {
+		return err
+	}
+	for i := len(m.stack) - 1; i >= 0; i-- {
+		m.stack[i].Exit()
+	}
+	m.stack = []Scene{scene}
+	m.names = []string{name}
+	scene.Enter()
+	return nil
+}
+
+// =====================================================================================================================
+
+type RenderableScene struct {
+	renderables []Renderable
+	camera      *Camera
+	world       *World
+	load        func(s *RenderableScene)
+	onBack      func()
+	onExit      func()
+}
+
+func NewRenderableScene(load func(s *RenderableScene), onBack func()) *RenderableScene {
+	return &RenderableScene{load: load, onBack: onBack}
+}
+
+func (s *RenderableScene) Enter() {
+	s.renderables = nil
+	s.world = nil
+	s.camera = NewCamera()
+	if s.load != nil {
+		s.load(s)
+	}
+}
+
+func (s *RenderableScene) Exit() {
+	if s.onExit != nil {
+		s.onExit()
+	}
+	s.renderables = nil
+	s.onExit = nil
+}
+
+func (s *RenderableScene) Update(dt, sw, sh float64) error {
+	for _, object := range s.renderables {
+		object.Layout(sw, sh)
+	}
+	if s.world != nil {
+		s.world.Advance(dt)
+	}
+	return nil
+}
+
+func (s *RenderableScene) Draw(dst *Canvas) {
+	sw, sh := dst.Size()
+	dst.SetTransformation(s.camera.Transformation(sw, sh))
+	for _, object := range s.renderables {
+		object.Draw(dst)
+	}
+}
+
+func (s *RenderableScene) Back() {
+	if s.onBack != nil {
+		s.onBack()
+	}
+}
+
+func (s *RenderableScene) AddObject(obj Renderable) {
+	s.renderables = append(s.renderables, obj)
+}
+
+func (s *RenderableScene) Focusables() []Focusable {
+	var widgets []Focusable
+	for _, object := range s.renderables {
+		if w, ok := object.(Focusable); ok {
+			widgets = append(widgets, w)
+		}
+	}
+	return widgets
+}
+
+func (s *RenderableScene) OnExit(action func()) {
+	s.onExit = action
+}
+
+func (s *RenderableScene) SetWorld(world *World) {
+	s.world = world
+}
+
+func (s *RenderableScene) Camera() *Camera {
+	return s.camera
+}
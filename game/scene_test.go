@@ -0,0 +1,121 @@
+package game
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingScene logs its lifecycle calls as "<name>.<call>".
+type recordingScene struct {
+	name string
+	log  *[]string
+}
+
+func (s *recordingScene) Enter()                          { *s.log = append(*s.log, s.name+".enter") }
+func (s *recordingScene) Exit()                           { *s.log = append(*s.log, s.name+".exit") }
+func (s *recordingScene) Pause()                          { *s.log = append(*s.log, s.name+".pause") }
+func (s *recordingScene) Resume()                         { *s.log = append(*s.log, s.name+".resume") }
+func (s *recordingScene) Update(dt, sw, sh float64) error { return nil }
+func (s *recordingScene) Draw(dst *Canvas)                {}
+
+func newRecordingManager(log *[]string) *SceneManager {
+	m := NewSceneManager()
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		m.Register(name, func() Scene { return &recordingScene{name: name, log: log} })
+	}
+	return m
+}
+
+func TestSceneManagerFlush(t *testing.T) {
+	tests := []struct {
+		name  string
+		ops   func(m *SceneManager)
+		stack string
+		log   []string
+	}{
+		{
+			name:  "push",
+			ops:   func(m *SceneManager) { m.Push("a"); m.Push("b") },
+			stack: "a b",
+			log:   []string{"a.enter", "a.pause", "b.enter"},
+		},
+		{
+			name:  "pop resumes the scene below",
+			ops:   func(m *SceneManager) { m.Push("a"); m.Push("b"); m.Pop() },
+			stack: "a",
+			log:   []string{"a.enter", "a.pause", "b.enter", "b.exit", "a.resume"},
+		},
+		{
+			name:  "pop on a stack of one keeps the root",
+			ops:   func(m *SceneManager) { m.Push("a"); m.Pop() },
+			stack: "a",
+			log:   []string{"a.enter"},
+		},
+		{
+			name:  "pop on an empty stack",
+			ops:   func(m *SceneManager) { m.Pop() },
+			stack: "",
+			log:   nil,
+		},
+		{
+			name:  "replace swaps the top",
+			ops:   func(m *SceneManager) { m.Push("a"); m.Push("b"); m.Replace("c") },
+			stack: "a c",
+			log:   []string{"a.enter", "a.pause", "b.enter", "b.exit", "c.enter"},
+		},
+		{
+			name:  "replace on an empty stack",
+			ops:   func(m *SceneManager) { m.Replace("a") },
+			stack: "a",
+			log:   []string{"a.enter"},
+		},
+		{
+			name:  "reset exits everything top down",
+			ops:   func(m *SceneManager) { m.Push("a"); m.Push("b"); m.Reset("c") },
+			stack: "c",
+			log:   []string{"a.enter", "a.pause", "b.enter", "b.exit", "a.exit", "c.enter"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var log []string
+			m := newRecordingManager(&log)
+			test.ops(m)
+			if len(log) != 0 {
+				t.Fatalf("operations applied before Flush: %v", log)
+			}
+			if err := m.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if stack := strings.Join(m.names, " "); stack != test.stack {
+				t.Errorf("stack %q, want %q", stack, test.stack)
+			}
+			if m.Depth() != len(m.names) {
+				t.Errorf("depth %d with %d names", m.Depth(), len(m.names))
+			}
+			if !reflect.DeepEqual(log, test.log) {
+				t.Errorf("log %v, want %v", log, test.log)
+			}
+		})
+	}
+}
+
+func TestSceneManagerUnregistered(t *testing.T) {
+	var log []string
+	m := newRecordingManager(&log)
+	m.Push("a")
+	m.Push("missing")
+	m.Push("b")
+	if err := m.Flush(); err == nil {
+		t.Fatal("pushing an unregistered scene did not fail")
+	}
+	if m.CurrentName() != "a" {
+		t.Errorf("current scene %q, want a", m.CurrentName())
+	}
+	if err := m.Flush(); err != nil {
+		t.Errorf("the failed operations were kept %v", err)
+	}
+}
@@ -1,12 +1,15 @@
 package game
 
 import (
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Slider struct {
 	*RectObject
 	scale         float64
+	input         *Input
 	value         float64
 	sliderElement *SliderElement // Dodajemy element suwaka
 	action        func(float64)
@@ -26,7 +29,7 @@ func NewSliderElement(tex Drawable, area Rect) *SliderElement {
 	return s
 }
 
-func NewSlider(tex Drawable, sliderTex Drawable, area Rect, scale float64, action func(float64)) *Slider {
+func NewSlider(tex Drawable, sliderTex Drawable, area Rect, scale float64, input *Input, action func(float64)) *Slider {
 
 	left := area.Left + (area.width() / 2) - 10
 	right := area.Left + (area.width() / 2) + 10
@@ -38,6 +41,8 @@ func NewSlider(tex Drawable, sliderTex Drawable, area Rect, scale float64, actio
 	s := &Slider{
 		RectObject:    NewRectObject(tex, area),
 		scale:         scale,
+		input:         input,
+		value:         50,
 		sliderElement: NewSliderElement(sliderTex, cell),
 		action:        action,
 	}
@@ -47,13 +52,13 @@ func NewSlider(tex Drawable, sliderTex Drawable, area Rect, scale float64, actio
 
 func (s *Slider) Layout(sw, sh float64) {
 	x, y := ebiten.CursorPosition()
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton0) {
+	if s.input.JustPressed(ActionClick) {
 		if x > int(s.sliderElement.area.Left) && x < int(s.sliderElement.area.Right) && y > int(s.sliderElement.area.Top) && y < int(s.sliderElement.area.Bottom) {
 			s.sliderElement.dragging = true
 		}
 	}
 
-	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+	if !s.input.Pressed(ActionClick) {
 		s.sliderElement.dragging = false
 	}
 
@@ -72,6 +77,26 @@ func (s *Slider) Layout(sw, sh float64) {
 	}
 }
 
+func (s *Slider) Activate() {
+}
+
+func (s *Slider) Nudge(dir float64) bool {
+	s.SetValue(s.value + dir*10)
+	return true
+}
+
+func (s *Slider) SetValue(value float64) {
+	value = math.Max(0, math.Min(100, value))
+
+	lenght := s.area.Right - s.area.Left
+	position := s.area.Left + value/100*lenght
+	delta := position - s.sliderElement.area.Left - 10
+	s.sliderElement.area = s.sliderElement.area.Offset(delta, 0)
+	s.value = value
+
+	s.action(s.value)
+}
+
 func (s *Slider) Draw(dst *Canvas) {
 
 	s.RectObject.Draw(dst)
@@ -0,0 +1,66 @@
+package game
+
+// SlopeShape is the surface of a sloped ground tile. Up slopes rise towards the right and down slopes fall towards
+// the right, gentle slopes take two tiles to rise by one, a low and a high half.
+type SlopeShape int
+
+const (
+	SlopeUp SlopeShape = iota
+	SlopeDown
+	SlopeGentleUpLow
+	SlopeGentleUpHigh
+	SlopeGentleDownHigh
+	SlopeGentleDownLow
+)
+
+// heights returns how high the surface is at the left and at the right edge of the tile, as a fraction of the
+// tile height.
+func (s SlopeShape) heights() (left, right float64) {
+	switch s {
+	case SlopeUp:
+		return 0, 1
+	case SlopeDown:
+		return 1, 0
+	case SlopeGentleUpLow:
+		return 0, 0.5
+	case SlopeGentleUpHigh:
+		return 0.5, 1
+	case SlopeGentleDownHigh:
+		return 1, 0.5
+	case SlopeGentleDownLow:
+		return 0.5, 0
+	}
+	return 1, 1
+}
+
+func NewSlope(area Rect, shape SlopeShape, scale float64) *Ground {
+	g := NewGround(nil, area, true, scale)
+	g.sloped = true
+	g.slope = shape
+	return g
+}
+
+func (g *Ground) Sloped() bool {
+	return g.sloped
+}
+
+// SurfaceAt returns the y of the highest point of the ground under box. For a slope that is where the box edge on
+// the rising side is, so a box resting on the surface never cuts into it.
+func (g *Ground) SurfaceAt(box Rect) float64 {
+	if !g.sloped {
+		return g.area.Top
+	}
+
+	left, right := g.slope.heights()
+	x := box.Left
+	if right > left {
+		x = box.Right
+	}
+	t := (x - g.area.Left) / g.area.width()
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	return g.area.Bottom - (left+(right-left)*t)*g.area.height()
+}
@@ -7,6 +7,7 @@ import (
 	"image"
 	"io"
 
+	"github.com/PawelCedzich/AloneInTheWorld/AloneInTheWorld/levelmap"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
@@ -48,8 +49,75 @@ const (
 	MortyWalkingT
 	MortyJoyFulT
 	MortySadFulT
+	SpikesT
+	LavaT
+	WaterT
+	CoinT
+	GemT
+	PlatformT
+	PlatformOneWayT
+	OneWayLeftT
+	OneWayMidT
+	OneWayRightT
+	OneWaySingleT
+	SlopeUpT
+	SlopeDownT
+	SlopeUpFillT
+	SlopeDownFillT
+	LadderT
+	KeyT
+	DoorT
+	DoorOpenT
+	SwitchT
+	SwitchPressedT
+	LeverOffT
+	LeverOnT
+	BlockT
 )
 
+// tileTextures are the textures of the tiles a levelmap names.
+var tileTextures = map[levelmap.Tile]Texture{
+	levelmap.TileGroundMid:            GroundMidT,
+	levelmap.TileGroundLeft:           GroundLeftT,
+	levelmap.TileGroundRight:          GroundRightT,
+	levelmap.TileGroundSingle:         GroundSingleT,
+	levelmap.TileGroundFill:           GroundFillT,
+	levelmap.TileGroundFillSingle:     GroundFillSingleT,
+	levelmap.TileGroundFillLeft:       GroundFillLeftT,
+	levelmap.TileGroundFillRight:      GroundFillRightT,
+	levelmap.TileGroundFillDeep:       GroundFillDeepT,
+	levelmap.TileGroundFillDeepSingle: GroundFillDeepSingleT,
+	levelmap.TileGroundFillDeepLeft:   GroundFillDeepLeftT,
+	levelmap.TileGroundFillDeepRight:  GroundFillDeepRightT,
+	levelmap.TileGroundFillDeeper:     GroundFillDeeperT,
+	levelmap.TileGroundFillDeeper2:    GroundFillDeeper2T,
+	levelmap.TileGroundFillDeeper3:    GroundFillDeeper3T,
+	levelmap.TileOneWayLeft:           OneWayLeftT,
+	levelmap.TileOneWayMid:            OneWayMidT,
+	levelmap.TileOneWayRight:          OneWayRightT,
+	levelmap.TileOneWaySingle:         OneWaySingleT,
+	levelmap.TileSlopeUp:              SlopeUpT,
+	levelmap.TileSlopeDown:            SlopeDownT,
+	levelmap.TileSlopeUpFill:          SlopeUpFillT,
+	levelmap.TileSlopeDownFill:        SlopeDownFillT,
+	levelmap.TileLadder:               LadderT,
+	levelmap.TileSpikes:               SpikesT,
+	levelmap.TileLava:                 LavaT,
+	levelmap.TileWater:                WaterT,
+	levelmap.TilePlatform:             PlatformT,
+	levelmap.TilePlatformOneWay:       PlatformOneWayT,
+	levelmap.TileCoin:                 CoinT,
+	levelmap.TileGem:                  GemT,
+	levelmap.TileKey:                  KeyT,
+	levelmap.TileDoor:                 DoorT,
+	levelmap.TileDoorOpen:             DoorOpenT,
+	levelmap.TileSwitch:               SwitchT,
+	levelmap.TileSwitchPressed:        SwitchPressedT,
+	levelmap.TileLeverOff:             LeverOffT,
+	levelmap.TileLeverOn:              LeverOnT,
+	levelmap.TileBlock:                BlockT,
+}
+
 type TextureManager struct {
 	textures map[Texture]*ebiten.Image
 }
@@ -74,6 +142,19 @@ func (t *TextureManager) LoadTexture(id Texture) *ebiten.Image {
 	return tex
 }
 
+func (t *TextureManager) Texture(id Texture) Drawable {
+	return NewDrawableTexture(t.LoadTexture(id))
+}
+
+func (t *TextureManager) Animation(id Texture, atlas []byte) Drawable {
+	atl, err := ParseTextureAtlas(bytes.NewReader(atlas))
+	if err != nil {
+		panic(fmt.Errorf("invalid state by parsing texture atlas %w", err))
+	}
+
+	return NewTextureAnimation(t.LoadTexture(id), atl, 30)
+}
+
 // =====================================================================================================================
 
 type TextureAtlas struct {
@@ -133,8 +214,8 @@ func NewTextureAnimation(tex *ebiten.Image, atlas TextureAtlas, fps float64) *Te
 	return t
 }
 
-func (t *TextureAnimation) Update() {
-	delta := t.fps / 60
+func (t *TextureAnimation) Update(dt float64) {
+	delta := t.fps * dt
 	t.idx += delta
 	if t.idx > float64(len(t.frames)-1) {
 		t.idx = 0
@@ -177,7 +258,7 @@ func NewDrawableTexture(tex *ebiten.Image) DrawableTexture {
 	return d
 }
 
-func (d DrawableTexture) Update() {
+func (d DrawableTexture) Update(dt float64) {
 }
 
 func (d DrawableTexture) Draw(dst *Canvas) {
@@ -200,19 +281,19 @@ func (d DrawableTexture) Pivot() Vec {
 // =====================================================================================================================
 
 type AnimationGroup struct {
-	animations []*TextureAnimation
+	animations []Drawable
 	idx        int
 }
 
-func NewAnimationGroup(animations ...*TextureAnimation) *AnimationGroup {
+func NewAnimationGroup(animations ...Drawable) *AnimationGroup {
 	a := &AnimationGroup{
 		animations: animations,
 	}
 	return a
 }
 
-func (a AnimationGroup) Update() {
-	a.animations[a.idx].Update()
+func (a AnimationGroup) Update(dt float64) {
+	a.animations[a.idx].Update(dt)
 }
 
 func (a AnimationGroup) Draw(dst *Canvas) {
@@ -0,0 +1,74 @@
+package game
+
+const (
+	TickRate   = 60
+	FixedDelta = 1.0 / TickRate
+	// maxStepsPerAdvance keeps a long hitch (window drag, breakpoint) from freezing the game while it catches up.
+	maxStepsPerAdvance = 10
+)
+
+// Stepper is implemented by everything that takes part in the simulation. dt is always FixedDelta.
+type Stepper interface {
+	Step(dt float64)
+}
+
+type World struct {
+	steppers    []Stepper
+	input       *Input
+	source      func() ActionSet
+	recorder    *Replay
+	accumulator float64
+	ticks       int
+}
+
+func NewWorld(source func() ActionSet) *World {
+	return &World{
+		input:  NewInput(),
+		source: source,
+	}
+}
+
+func (w *World) Add(s Stepper) {
+	w.steppers = append(w.steppers, s)
+}
+
+// Input is advanced once per step, so JustPressed fires on exactly one step no matter how many steps a frame runs.
+func (w *World) Input() *Input {
+	return w.input
+}
+
+func (w *World) Ticks() int {
+	return w.ticks
+}
+
+// SetRecorder makes the world append the actions of every step to the replay.
+func (w *World) SetRecorder(r *Replay) {
+	w.recorder = r
+}
+
+func (w *World) Step() {
+	pressed := w.source()
+	if w.recorder != nil {
+		w.recorder.Record(pressed)
+	}
+	w.input.Update(pressed)
+	for _, s := range w.steppers {
+		s.Step(FixedDelta)
+	}
+	w.ticks++
+}
+
+// Advance runs as many fixed steps as fit into the elapsed time and keeps the remainder for the next call.
+func (w *World) Advance(elapsed float64) {
+	w.accumulator += elapsed
+	steps := 0
+	for w.accumulator >= FixedDelta-1e-9 {
+		if steps == maxStepsPerAdvance {
+			w.accumulator = 0
+			break
+		}
+		w.Step()
+		w.accumulator -= FixedDelta
+		steps++
+	}
+}
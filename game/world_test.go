@@ -0,0 +1,56 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// playState is what a tick of the world decides, for comparing runs.
+type playState struct {
+	Tick     int
+	Player   Rect
+	Velocity Vec
+	Grounded bool
+	Health   int
+	Lives    int
+	Npcs     []Rect
+	Won      bool
+	Lost     bool
+}
+
+func stateOf(h *Harness) playState {
+	p := h.Player()
+	return playState{
+		Tick:     h.Tick(),
+		Player:   h.PlayerBox(),
+		Velocity: p.velocity,
+		Grounded: h.Grounded(),
+		Health:   p.Health(),
+		Lives:    p.Lives(),
+		Npcs:     h.NpcPositions(),
+		Won:      h.Won(),
+		Lost:     h.Lost(),
+	}
+}
+
+func TestWorldDeterministic(t *testing.T) {
+	script := InputScript{}.
+		Hold(40, ActionMoveRight).
+		Hold(3, ActionMoveRight, ActionJump).
+		Hold(20, ActionMoveRight).
+		Hold(12, ActionMoveLeft, ActionJump).
+		Hold(30).
+		Hold(1, ActionMoveRight, ActionJump)
+
+	for _, lvl := range []int{1, 2} {
+		a := levelHarness(t, lvl, script)
+		b := levelHarness(t, lvl, script)
+		for tick := 0; tick < 600; tick += 50 {
+			a.Run(50)
+			b.Run(50)
+			if sa, sb := stateOf(a), stateOf(b); !reflect.DeepEqual(sa, sb) {
+				t.Fatalf("level %d diverged by tick %d\n%+v\n%+v", lvl, sa.Tick, sa, sb)
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package levelmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Component is a character of the ASCII level format. The terrain ones stay in Map.Tiles, the others become
+// objects.
+type Component rune
+
+const (
+	Space      Component = ' '
+	Ground     Component = 'X'
+	Player     Component = 'P'
+	Goal       Component = 'G'
+	Raccoon    Component = 'R'
+	Checkpoint Component = 'C'
+	Spikes     Component = 'S'
+	Lava       Component = 'L'
+	Water      Component = 'W'
+	KillZone   Component = 'K'
+	Coin       Component = 'O'
+	Gem        Component = '*'
+	// moving platforms follow the track next to them, or the waypoints 1 to 9 when there is none
+	Platform       Component = 'M'
+	OneWayPlatform Component = 'm'
+	TrackX         Component = '.'
+	TrackY         Component = ':'
+	OneWay         Component = '='
+	// slopes rise to the right (up) or fall to the right (down), gentle ones are written as a low and a high half
+	SlopeUp        Component = '/'
+	SlopeDown      Component = '\\'
+	GentleUpLow    Component = 'u'
+	GentleUpHigh   Component = 'U'
+	GentleDownHigh Component = 'D'
+	GentleDownLow  Component = 'd'
+	Ladder         Component = 'H'
+)
+
+// asciiObjects are the characters that place an object instead of terrain.
+var asciiObjects = map[Component]ObjectType{
+	Player:     ObjectPlayer,
+	Goal:       ObjectGoal,
+	Raccoon:    ObjectNpc,
+	Checkpoint: ObjectCheckpoint,
+	Coin:       ObjectCoin,
+	Gem:        ObjectGem,
+}
+
+// legendKinds are the kinds of objects the legend of an ASCII level can place.
+var legendKinds = map[string]ObjectType{
+	"key":    ObjectKey,
+	"door":   ObjectDoor,
+	"switch": ObjectSwitch,
+	"lever":  ObjectLever,
+	"block":  ObjectBlock,
+}
+
+func IsWaypoint(c Component) bool {
+	return c >= '1' && c <= '9'
+}
+
+func IsSlope(c Component) bool {
+	switch c {
+	case SlopeUp, SlopeDown, GentleUpLow, GentleUpHigh, GentleDownHigh, GentleDownLow:
+		return true
+	}
+	return false
+}
+
+// SlopeRises reports whether the slope rises towards the right.
+func SlopeRises(c Component) bool {
+	return c == SlopeUp || c == GentleUpLow || c == GentleUpHigh
+}
+
+// IsTerrain reports whether c is a character of the tile layer.
+func IsTerrain(c Component) bool {
+	switch c {
+	case Space, Ground, Spikes, Lava, Water, KillZone, Platform, OneWayPlatform, TrackX, TrackY, OneWay, Ladder:
+		return true
+	}
+	return IsSlope(c) || IsWaypoint(c)
+}
+
+// builtinComponent reports whether c has a meaning of its own, the legend cannot give it another one.
+func builtinComponent(c Component) bool {
+	_, object := asciiObjects[c]
+	return object || IsTerrain(c)
+}
+
+// legendEntry gives a character of an ASCII level a linked object, the legend below the tiles lists them one per
+// line as "<char> <kind> <link>", with a trailing "off" for blocks that start switched off:
+//
+//	a key red
+//	A door red
+//	b lever bridge
+//	B block bridge off
+//
+// Objects with the same link work together, a key opens the doors of its link and switches and levers switch
+// its blocks.
+type legendEntry struct {
+	typ  ObjectType
+	link string
+	off  bool
+}
+
+// ParseASCII converts a level written one character per cell, and after a blank line its legend, into a Map.
+// Characters the format does not know are left empty. Lines may end in CRLF.
+func ParseASCII(str string) (*Map, error) {
+
+	lines := strings.Split(strings.ReplaceAll(str, "\r\n", "\n"), "\n")
+	var legendLines []string
+	for lineNo, line := range lines {
+		if line == "" {
+			legendLines = lines[lineNo+1:]
+			lines = lines[:lineNo]
+			break
+		}
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("level must have at least 2 lines %d ", len(lines))
+	}
+
+	width := len(lines[0])
+	if width < 1 {
+		return nil, fmt.Errorf("level must have at least a single column to work %d", width)
+	}
+
+	legend := map[Component]legendEntry{}
+	for i, line := range legendLines {
+		lineNo := len(lines) + 1 + i
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		char, entry, err := parseLegendEntry(line)
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Column: -1, Err: fmt.Errorf("invalid legend %w", err)}
+		}
+		if _, ok := legend[char]; ok {
+			return nil, errorAt(lineNo, -1, "second legend entry for %q", char)
+		}
+		legend[char] = entry
+	}
+
+	m := &Map{Tiles: make([][]Component, len(lines), len(lines))}
+
+	hasPlayer := false
+	for lineNo, line := range lines {
+		// spaces past the width are empty cells outside of the level, editors leave them behind
+		if len(line) > width && strings.TrimRight(line[width:], " ") == "" {
+			line = line[:width]
+		}
+		if len(line) != width {
+			return nil, errorAt(lineNo, -1, "the width of the lvl must be same, the line has a width of %d expected %d", len(line), width)
+		}
+
+		m.Tiles[lineNo] = make([]Component, width, width)
+		for runeNo, char := range line {
+			component := Component(char)
+			m.Tiles[lineNo][runeNo] = Space
+			if component == Player {
+				if hasPlayer {
+					return nil, errorAt(lineNo, runeNo, "second player declaration")
+				}
+				hasPlayer = true
+			}
+
+			if IsTerrain(component) {
+				m.Tiles[lineNo][runeNo] = component
+			} else if typ, ok := asciiObjects[component]; ok {
+				m.Objects = append(m.Objects, Object{Type: typ, X: runeNo, Y: lineNo})
+			} else if entry, ok := legend[component]; ok {
+				m.Objects = append(m.Objects, Object{
+					Type:  entry.typ,
+					X:     runeNo,
+					Y:     lineNo,
+					Props: Properties{Link: entry.link, Off: entry.off},
+				})
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func parseLegendEntry(line string) (Component, legendEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || len(fields) > 4 {
+		return 0, legendEntry{}, fmt.Errorf("expected <char> <kind> <link> [off] got %q", line)
+	}
+	if len(fields[0]) != 1 {
+		return 0, legendEntry{}, fmt.Errorf("%q is not a single character", fields[0])
+	}
+	char := Component(fields[0][0])
+	if builtinComponent(char) {
+		return 0, legendEntry{}, fmt.Errorf("%q is already a level component", char)
+	}
+	typ, ok := legendKinds[fields[1]]
+	if !ok {
+		return 0, legendEntry{}, fmt.Errorf("unknown kind %q", fields[1])
+	}
+	entry := legendEntry{typ: typ, link: fields[2]}
+	if len(fields) == 4 {
+		if typ != ObjectBlock || fields[3] != "off" && fields[3] != "on" {
+			return 0, legendEntry{}, fmt.Errorf("unexpected %q, only blocks can be on or off", fields[3])
+		}
+		entry.off = fields[3] == "off"
+	}
+	return char, entry, nil
+}
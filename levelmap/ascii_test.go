@@ -0,0 +1,73 @@
+package levelmap
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const legendLevel = `X    X
+XPaAGX
+XXXXXX
+
+a key red
+A door red`
+
+func TestParseASCIICRLF(t *testing.T) {
+	want, err := ParseASCII(legendLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseASCII(strings.ReplaceAll(legendLevel, "\n", "\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CRLF level parsed to %+v, want %+v", got, want)
+	}
+	if doors := got.ObjectsOf(ObjectDoor); len(doors) != 1 || doors[0].Props.Link != "red" {
+		t.Errorf("legend door not found in CRLF level, doors %+v", doors)
+	}
+}
+
+func TestParseASCIITrailingSpaces(t *testing.T) {
+	want, err := ParseASCII(legendLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseASCII(strings.Replace(legendLevel, "XXXXXX", "XXXXXX  ", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("level with trailing spaces parsed to %+v, want %+v", got, want)
+	}
+	if _, err := ParseASCII(strings.Replace(legendLevel, "XXXXXX", "XXXXXX X", 1)); err == nil {
+		t.Error("a line wider than the level was read")
+	}
+}
+
+func TestParseASCIIErrorPosition(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        string
+		line, column int
+	}{
+		{"second player", "X   X\nXP PX\nXXXXX", 1, 3},
+		{"short line", "X   X\nXP GX\nXXXX\nXXXXX", 2, -1},
+		{"bad legend", "X   X\nXPaGX\nXXXXX\n\na key", 4, -1},
+		{"second legend entry", "X   X\nXPaGX\nXXXXX\n\na key red\na door red", 5, -1},
+	}
+	for _, test := range tests {
+		_, err := ParseASCII(test.level)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: got %v, want a ParseError", test.name, err)
+			continue
+		}
+		if parseErr.Line != test.line || parseErr.Column != test.column {
+			t.Errorf("%s: error at %d,%d, want %d,%d", test.name, parseErr.Line, parseErr.Column, test.line, test.column)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package levelmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// jsonLevel is the JSON level format. Tiles is the terrain written with the characters of the ASCII format, one
+// string per row. The rows of the textures and decor layers use characters of their own that their palette maps
+// to tile names, a space is empty.
+//
+//	{
+//	  "tiles": ["X   X", "XXXXX"],
+//	  "textures": {"rows": ["", "s    "], "palette": {"s": "GroundSingle"}},
+//	  "decor": {"rows": [" f   "], "palette": {"f": "Ladder"}},
+//	  "objects": [{"type": "npc", "x": 2, "y": 0, "props": {"speed": 3, "target": true}}]
+//	}
+type jsonLevel struct {
+	Tiles    []string       `json:"tiles"`
+	Textures *jsonTileLayer `json:"textures,omitempty"`
+	Decor    *jsonTileLayer `json:"decor,omitempty"`
+	Objects  []Object       `json:"objects"`
+}
+
+type jsonTileLayer struct {
+	Rows    []string        `json:"rows"`
+	Palette map[string]Tile `json:"palette"`
+}
+
+// paletteChars are handed out to the tiles of a layer when a map is written as JSON.
+const paletteChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// ParseJSON reads a level in the JSON format, unknown fields and properties of the wrong type are errors.
+func ParseJSON(data []byte) (*Map, error) {
+	var file jsonLevel
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("cant decode level %w", err)
+	}
+
+	if len(file.Tiles) < 2 {
+		return nil, fmt.Errorf("level must have at least 2 rows of tiles %d", len(file.Tiles))
+	}
+	width := len(file.Tiles[0])
+	if width < 1 {
+		return nil, fmt.Errorf("level must have at least a single column to work %d", width)
+	}
+
+	m := &Map{Tiles: make([][]Component, len(file.Tiles))}
+	for y, row := range file.Tiles {
+		if len(row) != width {
+			return nil, errorAt(y, -1, "the width of the lvl must be same, the row has a width of %d expected %d", len(row), width)
+		}
+		m.Tiles[y] = make([]Component, width)
+		for x, char := range []byte(row) {
+			if !IsTerrain(Component(char)) {
+				return nil, errorAt(y, x, "unknown tile %q", char)
+			}
+			m.Tiles[y][x] = Component(char)
+		}
+	}
+
+	if file.Textures != nil {
+		textures, err := parseTileLayer("textures", *file.Textures, width, len(file.Tiles))
+		if err != nil {
+			return nil, err
+		}
+		m.Textures = textures
+	}
+	if file.Decor != nil {
+		decor, err := parseTileLayer("decor", *file.Decor, width, len(file.Tiles))
+		if err != nil {
+			return nil, err
+		}
+		m.Decor = decor
+	}
+
+	players := 0
+	for i, o := range file.Objects {
+		if err := o.validate(width, len(file.Tiles)); err != nil {
+			return nil, fmt.Errorf("invalid object %d %w", i, err)
+		}
+		if o.Type == ObjectPlayer {
+			players++
+		}
+	}
+	if players > 1 {
+		return nil, fmt.Errorf("level has %d players", players)
+	}
+	m.Objects = file.Objects
+
+	return m, nil
+}
+
+func parseTileLayer(name string, layer jsonTileLayer, width, height int) ([][]Tile, error) {
+	for char, tile := range layer.Palette {
+		if len(char) != 1 || char == " " {
+			return nil, fmt.Errorf("%s palette key %q is not a single character", name, char)
+		}
+		if !knownTile(tile) {
+			return nil, fmt.Errorf("%s palette maps %q to unknown tile %q", name, char, tile)
+		}
+	}
+	if len(layer.Rows) > height {
+		return nil, fmt.Errorf("%s has %d rows, the level only %d", name, len(layer.Rows), height)
+	}
+
+	tiles := make([][]Tile, len(layer.Rows))
+	for y, row := range layer.Rows {
+		if len(row) > width {
+			return nil, errorAt(y, -1, "%s row is wider than the level", name)
+		}
+		tiles[y] = make([]Tile, len(row))
+		for x, char := range []byte(row) {
+			if char == ' ' {
+				continue
+			}
+			tile, ok := layer.Palette[string(char)]
+			if !ok {
+				return nil, errorAt(y, x, "%s %q is not in the palette", name, char)
+			}
+			tiles[y][x] = tile
+		}
+	}
+	return tiles, nil
+}
+
+// EncodeJSON writes m in the JSON format, converting an ASCII level to JSON is ParseASCII followed by EncodeJSON.
+func EncodeJSON(m *Map) ([]byte, error) {
+	file := jsonLevel{Objects: m.Objects}
+	if file.Objects == nil {
+		file.Objects = []Object{}
+	}
+	for _, line := range m.Tiles {
+		row := make([]byte, len(line))
+		for x, component := range line {
+			row[x] = byte(component)
+		}
+		file.Tiles = append(file.Tiles, string(row))
+	}
+
+	if len(m.Textures) > 0 {
+		textures, err := encodeTileLayer("textures", m.Textures)
+		if err != nil {
+			return nil, err
+		}
+		file.Textures = textures
+	}
+	if len(m.Decor) > 0 {
+		decor, err := encodeTileLayer("decor", m.Decor)
+		if err != nil {
+			return nil, err
+		}
+		file.Decor = decor
+	}
+
+	return json.MarshalIndent(file, "", "  ")
+}
+
+func encodeTileLayer(name string, tiles [][]Tile) (*jsonTileLayer, error) {
+	chars := map[Tile]byte{}
+	var used []Tile
+	for _, row := range tiles {
+		for _, tile := range row {
+			if _, ok := chars[tile]; ok || tile == "" {
+				continue
+			}
+			chars[tile] = 0
+			used = append(used, tile)
+		}
+	}
+	if len(used) > len(paletteChars) {
+		return nil, fmt.Errorf("%s uses %d tiles, at most %d fit in a palette", name, len(used), len(paletteChars))
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i] < used[j] })
+
+	layer := &jsonTileLayer{Palette: map[string]Tile{}}
+	for i, tile := range used {
+		chars[tile] = paletteChars[i]
+		layer.Palette[string(paletteChars[i])] = tile
+	}
+	for _, row := range tiles {
+		line := make([]byte, len(row))
+		for x, tile := range row {
+			line[x] = ' '
+			if tile != "" {
+				line[x] = chars[tile]
+			}
+		}
+		layer.Rows = append(layer.Rows, string(line))
+	}
+	return layer, nil
+}
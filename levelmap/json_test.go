@@ -0,0 +1,120 @@
+package levelmap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	legend, err := ParseASCII(legendLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range map[string]*Map{"level1": level1(t), "legend": legend} {
+		data, err := EncodeJSON(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Parse(string(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("%s: converted to JSON and back as %+v, want %+v", name, got, m)
+		}
+	}
+}
+
+const jsonLevelData = `{
+  "tiles": ["X   X", "XXXXX"],
+  "textures": {"rows": ["", "sm  s"], "palette": {"s": "GroundSingle", "m": "GroundMid"}},
+  "decor": {"rows": [" f"], "palette": {"f": "Ladder"}},
+  "objects": [
+    {"type": "player", "x": 1, "y": 0},
+    {"type": "npc", "x": 2, "y": 0, "props": {"speed": 3, "target": true, "patrolLeft": 1}},
+    {"type": "block", "x": 3, "y": 0, "props": {"link": "red", "off": true}}
+  ]
+}`
+
+func TestParseJSON(t *testing.T) {
+	m, err := ParseJSON([]byte(jsonLevelData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Tile{{}, {TileGroundSingle, TileGroundMid, "", "", TileGroundSingle}}
+	if !reflect.DeepEqual(m.Textures, want) {
+		t.Errorf("textures %q, want %q", m.Textures, want)
+	}
+	if want := [][]Tile{{"", TileLadder}}; !reflect.DeepEqual(m.Decor, want) {
+		t.Errorf("decor %q, want %q", m.Decor, want)
+	}
+
+	npcs := m.ObjectsOf(ObjectNpc)
+	if len(npcs) != 1 {
+		t.Fatalf("%d npcs, want 1", len(npcs))
+	}
+	props := npcs[0].Props
+	if props.Speed == nil || *props.Speed != 3 || props.Target == nil || !*props.Target ||
+		props.PatrolLeft == nil || *props.PatrolLeft != 1 {
+		t.Errorf("npc properties %+v, want speed 3, target and a patrol of 1 to the left", props)
+	}
+	if props.StunDuration != nil || props.PushPower != nil || props.PatrolRight != nil {
+		t.Errorf("npc properties %+v set without being in the file", props)
+	}
+	if blocks := m.ObjectsOf(ObjectBlock); len(blocks) != 1 || blocks[0].Props != (Properties{Link: "red", Off: true}) {
+		t.Errorf("blocks %+v, want one linked to red and off", blocks)
+	}
+
+	data, err := EncodeJSON(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, m) {
+		t.Errorf("encoded and parsed again as %+v, want %+v", again, m)
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	level := func(objects string) string {
+		return `{"tiles": ["X   X", "XXXXX"], "objects": [` + objects + `]}`
+	}
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown field", `{"tiles": ["X X", "XXX"], "objects": [], "music": "main"}`, `unknown field "music"`},
+		{"unknown property", level(`{"type": "npc", "x": 1, "y": 0, "props": {"speeed": 3}}`), `unknown field "speeed"`},
+		{"property of the wrong type", level(`{"type": "npc", "x": 1, "y": 0, "props": {"speed": "fast"}}`), "speed"},
+		{"unknown tile", `{"tiles": ["X?X", "XXX"], "objects": []}`, `unknown tile '?'`},
+		{"unknown object", level(`{"type": "dragon", "x": 1, "y": 0}`), `unknown object type "dragon"`},
+		{"outside", level(`{"type": "coin", "x": 5, "y": 0}`), "outside of the level"},
+		{"npc property", level(`{"type": "coin", "x": 1, "y": 0, "props": {"speed": 3}}`), "has npc properties"},
+		{"missing link", level(`{"type": "door", "x": 1, "y": 0}`), "has no link"},
+		{"unlinked type", level(`{"type": "gem", "x": 1, "y": 0, "props": {"link": "red"}}`), "cant have a link"},
+		{"off", level(`{"type": "lever", "x": 1, "y": 0, "props": {"link": "red", "off": true}}`), "only blocks can"},
+		{"negative patrol", level(`{"type": "npc", "x": 1, "y": 0, "props": {"patrolRight": -1}}`), "negative patrol"},
+		{"second player", level(`{"type": "player", "x": 1, "y": 0}, {"type": "player", "x": 2, "y": 0}`), "2 players"},
+		{
+			"palette tile",
+			`{"tiles": ["X X", "XXX"], "decor": {"rows": ["f"], "palette": {"f": "Flower"}}, "objects": []}`,
+			`unknown tile "Flower"`,
+		},
+		{
+			"missing palette entry",
+			`{"tiles": ["X X", "XXX"], "decor": {"rows": ["g"], "palette": {"f": "Ladder"}}, "objects": []}`,
+			"not in the palette",
+		},
+	}
+	for _, test := range tests {
+		_, err := ParseJSON([]byte(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want one containing %q", test.name, err, test.want)
+		}
+	}
+}
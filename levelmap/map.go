@@ -0,0 +1,339 @@
+// Package levelmap describes levels independently of the game engine: the tiles, the decoration and the objects
+// placed in them, read from the ASCII or the JSON level format or from Tiled maps. Tools that must not open a window use it to
+// inspect and draw levels the same way the game builds them.
+package levelmap
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	// CellSize is the size of a level cell in unscaled pixels.
+	CellSize = 50.0
+	// ThinHeight is the thickness of one-way grounds and moving platforms in unscaled pixels, they sit at the top
+	// of their cell.
+	ThinHeight = 20.0
+)
+
+// ParseError is an error at a position of a level. Line and Column count from 0 like the cells do, for ASCII levels
+// they are the line and character in the file, for the other formats the row and column of the tile. Column is -1
+// when the whole line is wrong. The message counts from 1 like editors do.
+type ParseError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func errorAt(line, column int, format string, args ...interface{}) error {
+	return &ParseError{Line: line, Column: column, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *ParseError) Error() string {
+	if e.Column < 0 {
+		return fmt.Sprintf("line %d %v", e.Line+1, e.Err)
+	}
+	return fmt.Sprintf("line %d column %d %v", e.Line+1, e.Column+1, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Rect is an area in unscaled level pixels.
+type Rect struct {
+	Left, Top, Right, Bottom float64
+}
+
+// Point is a position in unscaled level pixels.
+type Point struct {
+	X, Y float64
+}
+
+// Cell returns the area of the cell at x, y.
+func Cell(x, y int) Rect {
+	return Rect{
+		Left:   float64(x) * CellSize,
+		Top:    float64(y) * CellSize,
+		Right:  float64(x+1) * CellSize,
+		Bottom: float64(y+1) * CellSize,
+	}
+}
+
+// Map is a parsed level. Tiles holds the terrain, every row has the same width, Textures overrides the texture
+// autotiling picks for ground cells, Decor holds textures drawn over the terrain without colliding and Objects
+// holds everything else in reading order.
+type Map struct {
+	Tiles    [][]Component
+	Textures [][]Tile
+	Decor    [][]Tile
+	Objects  []Object
+}
+
+// Load reads the level file name from fsys, Tiled maps by their .tmx or .tmj extension and any other file with Parse.
+func Load(fsys fs.FS, name string) (*Map, error) {
+	switch path.Ext(name) {
+	case ".tmx", ".tmj":
+		return LoadTiled(fsys, name)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("cant read level %w", err)
+	}
+	return Parse(string(data))
+}
+
+// Parse reads a level in the JSON format when it starts with '{', and in the ASCII format otherwise.
+func Parse(data string) (*Map, error) {
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		return ParseJSON([]byte(data))
+	}
+	return ParseASCII(data)
+}
+
+func (m *Map) Width() int {
+	if len(m.Tiles) == 0 {
+		return 0
+	}
+	return len(m.Tiles[0])
+}
+
+func (m *Map) Height() int {
+	return len(m.Tiles)
+}
+
+// Get returns the terrain at x, y, cells outside of the map are empty.
+func (m *Map) Get(x, y int) Component {
+	if x < 0 || y < 0 || y >= len(m.Tiles) || x >= len(m.Tiles[0]) {
+		return Space
+	}
+	return m.Tiles[y][x]
+}
+
+// DecorAt returns the decoration at x, y, an empty Tile when there is none.
+func (m *Map) DecorAt(x, y int) Tile {
+	return tileAt(m.Decor, x, y)
+}
+
+// TextureAt returns the texture the ground at x, y is drawn with instead of the autotiled one, an empty Tile when
+// there is none.
+func (m *Map) TextureAt(x, y int) Tile {
+	return tileAt(m.Textures, x, y)
+}
+
+func tileAt(layer [][]Tile, x, y int) Tile {
+	if x < 0 || y < 0 || y >= len(layer) || x >= len(layer[y]) {
+		return ""
+	}
+	return layer[y][x]
+}
+
+// ObjectsOf returns the objects of the given type in reading order.
+func (m *Map) ObjectsOf(typ ObjectType) []Object {
+	var objects []Object
+	for _, o := range m.Objects {
+		if o.Type == typ {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// MergedGround greedily joins the ground cells into rectangles. Each rectangle grows to the right first and then
+// down as long as the row below has a run of ground with the same ends, which turns a solid block into a single
+// collider and leaves no seams on flat floors. Runs that reach further than the rectangle start one of their own,
+// so a floor under columns of different heights stays whole instead of being cut into a rectangle per column.
+func (m *Map) MergedGround() []Rect {
+	if len(m.Tiles) == 0 {
+		return nil
+	}
+
+	height, width := len(m.Tiles), len(m.Tiles[0])
+	used := make([][]bool, height)
+	for y := range used {
+		used[y] = make([]bool, width)
+	}
+	free := func(x, y int) bool {
+		return m.Tiles[y][x] == Ground && !used[y][x]
+	}
+	ground := func(x, y int) bool {
+		return x >= 0 && x < width && m.Tiles[y][x] == Ground
+	}
+
+	var rects []Rect
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !free(x, y) {
+				continue
+			}
+
+			w := 1
+			for x+w < width && free(x+w, y) {
+				w++
+			}
+
+			h := 1
+			for y+h < height && !ground(x-1, y+h) && !ground(x+w, y+h) {
+				row := true
+				for i := 0; i < w; i++ {
+					if !free(x+i, y+h) {
+						row = false
+						break
+					}
+				}
+				if !row {
+					break
+				}
+				h++
+			}
+
+			for j := 0; j < h; j++ {
+				for i := 0; i < w; i++ {
+					used[y+j][x+i] = true
+				}
+			}
+			rects = append(rects, Rect{
+				Left:   float64(x) * CellSize,
+				Top:    float64(y) * CellSize,
+				Right:  float64(x+w) * CellSize,
+				Bottom: float64(y+h) * CellSize,
+			})
+		}
+	}
+
+	return rects
+}
+
+// MergedOneWay joins runs of one-way cells in a row into a single collider at the top of the cells.
+func (m *Map) MergedOneWay() []Rect {
+	var rects []Rect
+	for y, line := range m.Tiles {
+		for x := 0; x < len(line); x++ {
+			if line[x] != OneWay {
+				continue
+			}
+			start := x
+			for x+1 < len(line) && line[x+1] == OneWay {
+				x++
+			}
+			rects = append(rects, Rect{
+				Left:   float64(start) * CellSize,
+				Top:    float64(y) * CellSize,
+				Right:  float64(x+1) * CellSize,
+				Bottom: float64(y)*CellSize + ThinHeight,
+			})
+		}
+	}
+	return rects
+}
+
+// Ladders joins the ladder cells of every column into ladders.
+func (m *Map) Ladders() []Rect {
+	var ladders []Rect
+	if len(m.Tiles) == 0 {
+		return ladders
+	}
+	for x := range m.Tiles[0] {
+		for y := 0; y < len(m.Tiles); y++ {
+			if m.Tiles[y][x] != Ladder {
+				continue
+			}
+			top := y
+			for y+1 < len(m.Tiles) && m.Tiles[y+1][x] == Ladder {
+				y++
+			}
+			ladders = append(ladders, Rect{
+				Left:   float64(x) * CellSize,
+				Top:    float64(top) * CellSize,
+				Right:  float64(x+1) * CellSize,
+				Bottom: float64(y+1) * CellSize,
+			})
+		}
+	}
+	return ladders
+}
+
+// PlatformRun returns how many cells wide the moving platform starting at x, y is, 0 when no platform starts
+// there.
+func (m *Map) PlatformRun(x, y int) int {
+	component := m.Get(x, y)
+	if component != Platform && component != OneWayPlatform || m.Get(x-1, y) == component {
+		return 0
+	}
+	width := 1
+	for m.Get(x+width, y) == component {
+		width++
+	}
+	return width
+}
+
+// PlatformPath returns the points the top left corner of a platform width cells wide travels through. A platform
+// with a '.' track beside it goes back and forth along the track, one with a ':' track above or below it goes up
+// and down, any other one loops through the waypoints 1 to 9 of the level and returns to where it started.
+func (m *Map) PlatformPath(x, y, width int) []Point {
+	cell := func(x, y int) Point {
+		return Point{X: float64(x) * CellSize, Y: float64(y) * CellSize}
+	}
+
+	left, right := x, x+width-1
+	for m.Get(left-1, y) == TrackX {
+		left--
+	}
+	for m.Get(right+1, y) == TrackX {
+		right++
+	}
+	if left != x || right != x+width-1 {
+		return []Point{cell(right-width+1, y), cell(left, y)}
+	}
+
+	top, bottom := y, y
+	for m.Get(x, top-1) == TrackY {
+		top--
+	}
+	for m.Get(x, bottom+1) == TrackY {
+		bottom++
+	}
+	if top != y || bottom != y {
+		return []Point{cell(x, bottom), cell(x, top)}
+	}
+
+	var path []Point
+	for waypoint := Component('1'); waypoint <= '9'; waypoint++ {
+		for wy, line := range m.Tiles {
+			for wx, component := range line {
+				if component == waypoint {
+					path = append(path, cell(wx, wy))
+				}
+			}
+		}
+	}
+	return append(path, cell(x, y))
+}
+
+// GroundGroup returns how many cells an npc standing at x, y can walk to the left and to the right before the
+// ground under it ends.
+func (m *Map) GroundGroup(x, y int) (left, right int) {
+	for i := 1; i < 10; i++ {
+		if m.Get(x-i, y) != Ground {
+			if m.Get(x-i, y+1) == Ground {
+				left++
+			} else if m.Get(x-i, y+1) == Space {
+				break
+			}
+		}
+	}
+
+	for i := 1; i < 10; i++ {
+		if m.Get(x+i, y) != Ground {
+			if m.Get(x+i, y+1) == Ground {
+				right++
+			} else if m.Get(x+i, y+1) == Space {
+				break
+			}
+		}
+	}
+
+	return left, right
+}
@@ -0,0 +1,79 @@
+package levelmap
+
+import (
+	"os"
+	"testing"
+)
+
+func level1(t *testing.T) *Map {
+	t.Helper()
+	data, err := os.ReadFile("../assets/level1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMergedGroundCoversGround(t *testing.T) {
+	steps, err := ParseASCII("X    XX\nXX  XXX\nXXX XXX\nXXXXXXX\n X XXX ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legend, err := ParseASCII(legendLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, m := range map[string]*Map{"steps": steps, "legend": legend, "level1": level1(t)} {
+		covered := make([][]int, m.Height())
+		for y := range covered {
+			covered[y] = make([]int, m.Width())
+		}
+		for _, r := range m.MergedGround() {
+			for y := int(r.Top / CellSize); y < int(r.Bottom/CellSize); y++ {
+				for x := int(r.Left / CellSize); x < int(r.Right/CellSize); x++ {
+					covered[y][x]++
+				}
+			}
+		}
+		for y, row := range covered {
+			for x, n := range row {
+				want := 0
+				if m.Get(x, y) == Ground {
+					want = 1
+				}
+				if n != want {
+					t.Errorf("%s: cell %d %d %q is covered %d times", name, x, y, m.Get(x, y), n)
+				}
+			}
+		}
+	}
+}
+
+func TestMergedGroundBedrock(t *testing.T) {
+	m := level1(t)
+	rects := m.MergedGround()
+	// the bottom four rows are ground but for a two cell gap in the first
+	var bedrock []Rect
+	for _, r := range rects {
+		if r.Bottom > float64(m.Height()-4)*CellSize {
+			bedrock = append(bedrock, r)
+		}
+	}
+	if len(bedrock) > 3 {
+		t.Errorf("bedrock merged into %d rectangles %+v", len(bedrock), bedrock)
+	}
+	height, width := float64(m.Height()), float64(m.Width())
+	whole := Rect{Top: (height - 3) * CellSize, Right: width * CellSize, Bottom: height * CellSize}
+	found := false
+	for _, r := range bedrock {
+		found = found || r == whole
+	}
+	if !found {
+		t.Errorf("the full rows of the bedrock are not one rectangle %+v", whole)
+	}
+}
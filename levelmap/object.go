@@ -0,0 +1,90 @@
+package levelmap
+
+import "fmt"
+
+type ObjectType string
+
+const (
+	ObjectPlayer     ObjectType = "player"
+	ObjectGoal       ObjectType = "goal"
+	ObjectNpc        ObjectType = "npc"
+	ObjectCheckpoint ObjectType = "checkpoint"
+	ObjectCoin       ObjectType = "coin"
+	ObjectGem        ObjectType = "gem"
+	ObjectKey        ObjectType = "key"
+	ObjectDoor       ObjectType = "door"
+	ObjectSwitch     ObjectType = "switch"
+	ObjectLever      ObjectType = "lever"
+	ObjectBlock      ObjectType = "block"
+)
+
+// Object is something placed in a cell of the level, X and Y are the cell column and row.
+type Object struct {
+	Type  ObjectType `json:"type"`
+	X     int        `json:"x"`
+	Y     int        `json:"y"`
+	Props Properties `json:"props"`
+}
+
+// Properties configure an object, an unset property keeps the game's default. Npcs use Speed, StunDuration,
+// PushPower, Target and the patrol range, counted in cells to each side, that otherwise follows the ground they
+// stand on. Keys, doors, switches, levers and blocks use Link and blocks use Off.
+type Properties struct {
+	Speed        *float64 `json:"speed,omitempty"`
+	StunDuration *float64 `json:"stunDuration,omitempty"`
+	PushPower    *float64 `json:"pushPower,omitempty"`
+	Target       *bool    `json:"target,omitempty"`
+	PatrolLeft   *int     `json:"patrolLeft,omitempty"`
+	PatrolRight  *int     `json:"patrolRight,omitempty"`
+	Link         string   `json:"link,omitempty"`
+	Off          bool     `json:"off,omitempty"`
+}
+
+func (p Properties) npc() bool {
+	return p.Speed != nil || p.StunDuration != nil || p.PushPower != nil || p.Target != nil ||
+		p.PatrolLeft != nil || p.PatrolRight != nil
+}
+
+// Linked reports whether objects of the type work together through a link.
+func (t ObjectType) Linked() bool {
+	switch t {
+	case ObjectKey, ObjectDoor, ObjectSwitch, ObjectLever, ObjectBlock:
+		return true
+	}
+	return false
+}
+
+func (t ObjectType) known() bool {
+	switch t {
+	case ObjectPlayer, ObjectGoal, ObjectNpc, ObjectCheckpoint, ObjectCoin, ObjectGem:
+		return true
+	}
+	return t.Linked()
+}
+
+// validate checks that the object is inside a width by height level and only has the properties of its type.
+func (o Object) validate(width, height int) error {
+	if !o.Type.known() {
+		return fmt.Errorf("unknown object type %q", o.Type)
+	}
+	if o.X < 0 || o.Y < 0 || o.X >= width || o.Y >= height {
+		return fmt.Errorf("%s at %d,%d is outside of the level", o.Type, o.X, o.Y)
+	}
+	p := o.Props
+	if p.npc() && o.Type != ObjectNpc {
+		return fmt.Errorf("%s at %d,%d has npc properties", o.Type, o.X, o.Y)
+	}
+	if o.Type.Linked() && p.Link == "" {
+		return fmt.Errorf("%s at %d,%d has no link", o.Type, o.X, o.Y)
+	}
+	if !o.Type.Linked() && p.Link != "" {
+		return fmt.Errorf("%s at %d,%d cant have a link", o.Type, o.X, o.Y)
+	}
+	if p.Off && o.Type != ObjectBlock {
+		return fmt.Errorf("%s at %d,%d cant be off, only blocks can", o.Type, o.X, o.Y)
+	}
+	if p.PatrolLeft != nil && *p.PatrolLeft < 0 || p.PatrolRight != nil && *p.PatrolRight < 0 {
+		return fmt.Errorf("%s at %d,%d has a negative patrol range", o.Type, o.X, o.Y)
+	}
+	return nil
+}
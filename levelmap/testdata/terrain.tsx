@@ -0,0 +1,22 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="terrain" tilewidth="32" tileheight="32" tilecount="4" columns="0">
+ <grid orientation="orthogonal" width="1" height="1"/>
+ <tile id="0">
+  <image width="32" height="32" source="tiles/Tile_07.png"/>
+ </tile>
+ <tile id="1">
+  <image width="32" height="32" source="tiles/Spikes.png"/>
+ </tile>
+ <tile id="2">
+  <properties>
+   <property name="component" value="H"/>
+  </properties>
+  <image width="32" height="32" source="tiles/rope.png"/>
+ </tile>
+ <tile id="3">
+  <properties>
+   <property name="tile" value="GroundFill"/>
+  </properties>
+  <image width="32" height="32" source="tiles/moss.png"/>
+ </tile>
+</tileset>
@@ -0,0 +1,194 @@
+package levelmap
+
+import "math/rand"
+
+// Tile names a texture a level is drawn with, the decoration layer refers to textures by these names too.
+type Tile string
+
+const (
+	TileGroundMid            Tile = "GroundMid"
+	TileGroundLeft           Tile = "GroundLeft"
+	TileGroundRight          Tile = "GroundRight"
+	TileGroundSingle         Tile = "GroundSingle"
+	TileGroundFill           Tile = "GroundFill"
+	TileGroundFillSingle     Tile = "GroundFillSingle"
+	TileGroundFillLeft       Tile = "GroundFillLeft"
+	TileGroundFillRight      Tile = "GroundFillRight"
+	TileGroundFillDeep       Tile = "GroundFillDeep"
+	TileGroundFillDeepSingle Tile = "GroundFillDeepSingle"
+	TileGroundFillDeepLeft   Tile = "GroundFillDeepLeft"
+	TileGroundFillDeepRight  Tile = "GroundFillDeepRight"
+	TileGroundFillDeeper     Tile = "GroundFillDeeper"
+	TileGroundFillDeeper2    Tile = "GroundFillDeeper2"
+	TileGroundFillDeeper3    Tile = "GroundFillDeeper3"
+	TileOneWayLeft           Tile = "OneWayLeft"
+	TileOneWayMid            Tile = "OneWayMid"
+	TileOneWayRight          Tile = "OneWayRight"
+	TileOneWaySingle         Tile = "OneWaySingle"
+	TileSlopeUp              Tile = "SlopeUp"
+	TileSlopeDown            Tile = "SlopeDown"
+	TileSlopeUpFill          Tile = "SlopeUpFill"
+	TileSlopeDownFill        Tile = "SlopeDownFill"
+	TileLadder               Tile = "Ladder"
+	TileSpikes               Tile = "Spikes"
+	TileLava                 Tile = "Lava"
+	TileWater                Tile = "Water"
+	TilePlatform             Tile = "Platform"
+	TilePlatformOneWay       Tile = "PlatformOneWay"
+	TileCoin                 Tile = "Coin"
+	TileGem                  Tile = "Gem"
+	TileKey                  Tile = "Key"
+	TileDoor                 Tile = "Door"
+	TileDoorOpen             Tile = "DoorOpen"
+	TileSwitch               Tile = "Switch"
+	TileSwitchPressed        Tile = "SwitchPressed"
+	TileLeverOff             Tile = "LeverOff"
+	TileLeverOn              Tile = "LeverOn"
+	TileBlock                Tile = "Block"
+)
+
+// Tiles lists every tile name a level can use.
+var Tiles = []Tile{
+	TileGroundMid, TileGroundLeft, TileGroundRight, TileGroundSingle,
+	TileGroundFill, TileGroundFillSingle, TileGroundFillLeft, TileGroundFillRight,
+	TileGroundFillDeep, TileGroundFillDeepSingle, TileGroundFillDeepLeft, TileGroundFillDeepRight,
+	TileGroundFillDeeper, TileGroundFillDeeper2, TileGroundFillDeeper3,
+	TileOneWayLeft, TileOneWayMid, TileOneWayRight, TileOneWaySingle,
+	TileSlopeUp, TileSlopeDown, TileSlopeUpFill, TileSlopeDownFill,
+	TileLadder, TileSpikes, TileLava, TileWater, TilePlatform, TilePlatformOneWay, TileCoin, TileGem,
+	TileKey, TileDoor, TileDoorOpen, TileSwitch, TileSwitchPressed, TileLeverOff, TileLeverOn, TileBlock,
+}
+
+func knownTile(t Tile) bool {
+	for _, tile := range Tiles {
+		if tile == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Sprite is a tile drawn over an area of the level.
+type Sprite struct {
+	Tile Tile
+	Area Rect
+}
+
+// TerrainSprites returns what is drawn for the ground, slope, ladder or one-way cell at x, y. Deep ground picks
+// one of its variants with rng, so building a level twice with the same seed draws it the same.
+func (m *Map) TerrainSprites(x, y int, rng *rand.Rand) []Sprite {
+	cell := Cell(x, y)
+	component := m.Get(x, y)
+	switch {
+	case component == Ground:
+		if tile := m.TextureAt(x, y); tile != "" {
+			return []Sprite{{tile, cell}}
+		}
+		return []Sprite{{m.GroundTile(x, y, rng), cell}}
+	case component == Ladder:
+		return []Sprite{{TileLadder, cell}}
+	case component == OneWay:
+		return []Sprite{{m.CheckOneWayPlacement(x, y), Rect{cell.Left, cell.Top, cell.Right, cell.Top + ThinHeight}}}
+	case IsSlope(component):
+		tile := TileSlopeUp
+		if !SlopeRises(component) {
+			tile = TileSlopeDown
+		}
+		half := CellSize / 2
+		top := Rect{cell.Left, cell.Top, cell.Right, cell.Top + half}
+		bottom := Rect{cell.Left, cell.Top + half, cell.Right, cell.Bottom}
+		switch component {
+		case SlopeUp, SlopeDown:
+			return []Sprite{{tile, cell}}
+		case GentleUpLow, GentleDownLow:
+			return []Sprite{{tile, bottom}}
+		}
+		return []Sprite{{tile, top}, {SlopeFill(component), bottom}}
+	}
+	return nil
+}
+
+// GroundTile picks the texture of the ground cell at x, y from the cells around it: grass on top, fill below it
+// and deep fill further down, with edges where the ground ends to the left or right.
+func (m *Map) GroundTile(x, y int, rng *rand.Rand) Tile {
+	aboveGroundCount := 0
+	for i := 1; i <= 3; i++ {
+		if m.Get(x, y-i) == Ground {
+			aboveGroundCount++
+		} else if m.Get(x, y-i) == Space {
+			break
+		}
+	}
+
+	if above := m.Get(x, y-1); IsSlope(above) {
+		return SlopeFill(above)
+	} else if aboveGroundCount == 3 {
+		randomNumber := rng.Intn(30) + 1
+		if randomNumber >= 1 && randomNumber <= 19 {
+			return TileGroundFillDeeper
+		} else if randomNumber >= 20 && randomNumber <= 27 {
+			return TileGroundFillDeeper2
+		}
+		return TileGroundFillDeeper3
+	} else if aboveGroundCount == 2 {
+		return m.CheckTilePlacement(x, y, []Tile{TileGroundFillDeepLeft, TileGroundFillDeepRight, TileGroundFillDeepSingle, TileGroundFillDeep})
+	} else if aboveGroundCount == 1 {
+		return m.CheckTilePlacement(x, y, []Tile{TileGroundFillLeft, TileGroundFillRight, TileGroundFillSingle, TileGroundFill})
+	}
+	return m.CheckTilePlacement(x, y, []Tile{TileGroundLeft, TileGroundRight, TileGroundSingle, TileGroundMid})
+}
+
+// SlopeFill is the texture of the ground under a slope, its grass continues from the slope above.
+func SlopeFill(slope Component) Tile {
+	if SlopeRises(slope) {
+		return TileSlopeUpFill
+	}
+	return TileSlopeDownFill
+}
+
+func (m *Map) CheckOneWayPlacement(x, y int) Tile {
+	left, right := m.Get(x-1, y) == OneWay, m.Get(x+1, y) == OneWay
+	switch {
+	case left && right:
+		return TileOneWayMid
+	case right:
+		return TileOneWayLeft
+	case left:
+		return TileOneWayRight
+	}
+	return TileOneWaySingle
+}
+
+// solidCell reports whether the cell is ground or a slope, everything else counts as open space for autotiling.
+func (m *Map) solidCell(x, y int) bool {
+	component := m.Get(x, y)
+	return component == Ground || IsSlope(component)
+}
+
+func (m *Map) CheckLeftSpace(x, y int) bool {
+	if !m.solidCell(x-1, y) && m.solidCell(x+1, y) {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (m *Map) CheckRightSpace(x, y int) bool {
+	if m.solidCell(x-1, y) && !m.solidCell(x+1, y) {
+		return true
+	} else {
+		return false
+	}
+}
+
+// CheckTilePlacement picks from the left edge, right edge, single and middle variant of a tile in that order.
+func (m *Map) CheckTilePlacement(x, y int, tiles []Tile) Tile {
+	if m.CheckLeftSpace(x, y) {
+		return tiles[0]
+	} else if m.CheckRightSpace(x, y) {
+		return tiles[1]
+	} else if !m.solidCell(x+1, y) && !m.solidCell(x-1, y) {
+		return tiles[2]
+	}
+	return tiles[3]
+}
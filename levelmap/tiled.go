@@ -0,0 +1,435 @@
+package levelmap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+)
+
+// tiledFlipped are the bits of a tile id Tiled stores flips and rotations in.
+const tiledFlipped = 0xF0000000
+
+// tiledTile is what a tile of a Tiled tileset places: the terrain it stands for and optionally the exact texture.
+type tiledTile struct {
+	component Component
+	tile      Tile
+}
+
+// tiledImages maps the images of the shipped tileset and of the game's own tiles to what they place, so a map made
+// of them needs no tile properties. Ground tiles keep the texture the designer picked.
+var tiledImages = map[string]tiledTile{
+	"Tile_04.png":        {Ground, TileGroundFillDeeper},
+	"Tile_05.png":        {Ground, TileGroundSingle},
+	"Tile_06.png":        {Ground, TileGroundLeft},
+	"Tile_07.png":        {Ground, TileGroundMid},
+	"Tile_08.png":        {Ground, TileGroundRight},
+	"Tile_13.png":        {Ground, TileGroundFillDeepLeft},
+	"Tile_14.png":        {Ground, TileGroundFillDeep},
+	"Tile_16.png":        {Ground, TileGroundFillDeepRight},
+	"Tile_17.png":        {Ground, TileGroundFillDeepSingle},
+	"Tile_21.png":        {Ground, TileGroundFillLeft},
+	"Tile_22.png":        {Ground, TileGroundFill},
+	"Tile_23.png":        {Ground, TileGroundFillRight},
+	"Tile_30.png":        {Ground, TileGroundFillSingle},
+	"Tile_39.png":        {Ground, TileGroundFillDeeper3},
+	"Tile_40.png":        {Ground, TileGroundFillDeeper2},
+	"Tile_42.png":        {SlopeDown, ""},
+	"Tile_43.png":        {SlopeUp, ""},
+	"Tile_54.png":        {Ground, TileSlopeDownFill},
+	"Tile_55.png":        {Ground, TileSlopeUpFill},
+	"Ladder.png":         {Ladder, ""},
+	"Spikes.png":         {Spikes, ""},
+	"Lava.png":           {Lava, ""},
+	"Water.png":          {Water, ""},
+	"OneWayLeft.png":     {OneWay, ""},
+	"OneWayMid.png":      {OneWay, ""},
+	"OneWayRight.png":    {OneWay, ""},
+	"OneWaySingle.png":   {OneWay, ""},
+	"Platform.png":       {Platform, ""},
+	"PlatformOneWay.png": {OneWayPlatform, ""},
+}
+
+// tiledObjects maps the types, or classes, of Tiled objects to level objects.
+var tiledObjects = map[string]ObjectType{
+	"player":     ObjectPlayer,
+	"spawn":      ObjectPlayer,
+	"goal":       ObjectGoal,
+	"npc":        ObjectNpc,
+	"raccoon":    ObjectNpc,
+	"checkpoint": ObjectCheckpoint,
+	"coin":       ObjectCoin,
+	"gem":        ObjectGem,
+	"key":        ObjectKey,
+	"door":       ObjectDoor,
+	"switch":     ObjectSwitch,
+	"lever":      ObjectLever,
+	"block":      ObjectBlock,
+}
+
+// tiledMap is a Tiled map read from either of its file formats.
+type tiledMap struct {
+	orientation string
+	width       int
+	height      int
+	tileWidth   int
+	tileHeight  int
+	infinite    bool
+	tilesets    []tiledTileset
+	layers      []tiledLayer
+}
+
+type tiledTileset struct {
+	firstGID int
+	name     string
+	tiles    map[int]tiledTileInfo
+}
+
+type tiledTileInfo struct {
+	image string
+	props map[string]interface{}
+}
+
+type tiledLayer struct {
+	name    string
+	kind    string
+	props   map[string]interface{}
+	gids    []uint32
+	objects []tiledObject
+	layers  []tiledLayer
+}
+
+type tiledObject struct {
+	id     int
+	typ    string
+	x, y   float64
+	w, h   float64
+	gid    uint32
+	points bool
+	props  map[string]interface{}
+}
+
+// LoadTiled reads an orthogonal Tiled map saved as .tmx or .tmj, external tilesets are read from fsys relative to
+// the map. Every cell of the map is a cell of the level. Tile layers hold the terrain, or the decoration when the
+// layer is named "decor" or has a true "decor" property, and object layers hold the objects, matched by their type.
+// Tiles place what their "component" and "tile" properties say, tiles of the shipped tileset work without them.
+// Object properties are the ones of the JSON format.
+func LoadTiled(fsys fs.FS, name string) (*Map, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("cant read tiled map %w", err)
+	}
+
+	var tm *tiledMap
+	switch path.Ext(name) {
+	case ".tmx":
+		tm, err = decodeTMX(data, fsys, path.Dir(name))
+	case ".tmj":
+		tm, err = decodeTMJ(data, fsys, path.Dir(name))
+	default:
+		return nil, fmt.Errorf("%s is not a tiled map, expected .tmx or .tmj", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cant decode tiled map %s %w", name, err)
+	}
+
+	m, err := tm.convert()
+	if err != nil {
+		return nil, fmt.Errorf("cant convert tiled map %s %w", name, err)
+	}
+	return m, nil
+}
+
+func (tm *tiledMap) convert() (*Map, error) {
+	if tm.orientation != "orthogonal" {
+		return nil, fmt.Errorf("%q maps are not supported, only orthogonal ones", tm.orientation)
+	}
+	if tm.infinite {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+	if tm.width < 1 || tm.height < 2 {
+		return nil, fmt.Errorf("level must be at least 1 by 2 tiles, the map is %d by %d", tm.width, tm.height)
+	}
+	if tm.tileWidth < 1 || tm.tileHeight < 1 {
+		return nil, fmt.Errorf("invalid tile size %d by %d", tm.tileWidth, tm.tileHeight)
+	}
+
+	m := &Map{
+		Tiles:    make([][]Component, tm.height),
+		Textures: make([][]Tile, tm.height),
+		Decor:    make([][]Tile, tm.height),
+	}
+	for y := range m.Tiles {
+		m.Tiles[y] = make([]Component, tm.width)
+		m.Textures[y] = make([]Tile, tm.width)
+		m.Decor[y] = make([]Tile, tm.width)
+		for x := range m.Tiles[y] {
+			m.Tiles[y][x] = Space
+		}
+	}
+
+	if err := tm.convertLayers(m, tm.layers); err != nil {
+		return nil, err
+	}
+
+	players := 0
+	for i, o := range m.Objects {
+		if err := o.validate(tm.width, tm.height); err != nil {
+			return nil, fmt.Errorf("invalid object %d %w", i, err)
+		}
+		if o.Type == ObjectPlayer {
+			players++
+		}
+	}
+	if players > 1 {
+		return nil, fmt.Errorf("map has %d players", players)
+	}
+	return m, nil
+}
+
+func (tm *tiledMap) convertLayers(m *Map, layers []tiledLayer) error {
+	for _, layer := range layers {
+		var err error
+		switch layer.kind {
+		case "tilelayer":
+			err = tm.convertTiles(m, layer)
+		case "objectgroup":
+			err = tm.convertObjects(m, layer)
+		case "group":
+			err = tm.convertLayers(m, layer.layers)
+		default:
+			err = fmt.Errorf("%s layers are not supported", layer.kind)
+		}
+		if err != nil {
+			return fmt.Errorf("layer %q %w", layer.name, err)
+		}
+	}
+	return nil
+}
+
+func (tm *tiledMap) convertTiles(m *Map, layer tiledLayer) error {
+	if len(layer.gids) != tm.width*tm.height {
+		return fmt.Errorf("has %d tiles, expected %d", len(layer.gids), tm.width*tm.height)
+	}
+	decor, _ := layer.props["decor"].(bool)
+	decor = decor || strings.EqualFold(layer.name, "decor")
+
+	for i, gid := range layer.gids {
+		x, y := i%tm.width, i/tm.width
+		if gid == 0 {
+			continue
+		}
+		if gid&tiledFlipped != 0 {
+			return errorAt(y, x, "tile is flipped or rotated, that is not supported")
+		}
+		tile, err := tm.tile(gid)
+		if err != nil {
+			return &ParseError{Line: y, Column: x, Err: err}
+		}
+
+		if decor {
+			if tile.tile == "" {
+				return errorAt(y, x, "tile has no texture for the decoration")
+			}
+			m.Decor[y][x] = tile.tile
+			continue
+		}
+		if m.Tiles[y][x] != Space {
+			return errorAt(y, x, "tile covers terrain of another layer")
+		}
+		m.Tiles[y][x] = tile.component
+		if tile.component == Ground {
+			m.Textures[y][x] = tile.tile
+		}
+	}
+	return nil
+}
+
+// tile looks up what the tile with the given gid places.
+func (tm *tiledMap) tile(gid uint32) (tiledTile, error) {
+	var tileset *tiledTileset
+	for i := range tm.tilesets {
+		if tm.tilesets[i].firstGID <= int(gid) && (tileset == nil || tm.tilesets[i].firstGID > tileset.firstGID) {
+			tileset = &tm.tilesets[i]
+		}
+	}
+	if tileset == nil {
+		return tiledTile{}, fmt.Errorf("gid %d is in no tileset", gid)
+	}
+	id := int(gid) - tileset.firstGID
+	info := tileset.tiles[id]
+
+	tile, known := tiledImages[path.Base(info.image)]
+	if value, ok := info.props["component"]; ok {
+		char, ok := value.(string)
+		if !ok || len(char) != 1 || !IsTerrain(Component(char[0])) {
+			return tiledTile{}, fmt.Errorf("tile %d of tileset %q has component %v, expected a terrain character", id, tileset.name, value)
+		}
+		tile, known = tiledTile{component: Component(char[0])}, true
+	}
+	if value, ok := info.props["tile"]; ok {
+		name, ok := value.(string)
+		if !ok || !knownTile(Tile(name)) {
+			return tiledTile{}, fmt.Errorf("tile %d of tileset %q has unknown tile %v", id, tileset.name, value)
+		}
+		tile.tile = Tile(name)
+		if !known {
+			tile.component, known = Ground, true
+		}
+	}
+	if !known {
+		return tiledTile{}, fmt.Errorf("tile %d of tileset %q has no component or tile property and is not a known tile", id, tileset.name)
+	}
+	return tile, nil
+}
+
+func (tm *tiledMap) convertObjects(m *Map, layer tiledLayer) error {
+	for _, obj := range layer.objects {
+		typ, ok := tiledObjects[strings.ToLower(obj.typ)]
+		if !ok {
+			return fmt.Errorf("object %d has unknown type %q", obj.id, obj.typ)
+		}
+		if obj.points {
+			return fmt.Errorf("object %d is a polygon or polyline, only rectangles, points and tiles are supported", obj.id)
+		}
+
+		// the cell is the one under the center of the object, tile objects are anchored at their bottom
+		cx, cy := obj.x+obj.w/2, obj.y+obj.h/2
+		if obj.gid != 0 {
+			cy = obj.y - obj.h/2
+		}
+		o := Object{
+			Type: typ,
+			X:    int(math.Floor(cx / float64(tm.tileWidth))),
+			Y:    int(math.Floor(cy / float64(tm.tileHeight))),
+		}
+		props, err := tiledProperties(obj.props)
+		if err != nil {
+			return fmt.Errorf("object %d %w", obj.id, err)
+		}
+		o.Props = props
+		m.Objects = append(m.Objects, o)
+	}
+	return nil
+}
+
+// tiledProperties converts custom properties of an object into Properties, unknown ones and ones of the wrong type
+// are errors.
+func tiledProperties(props map[string]interface{}) (Properties, error) {
+	var p Properties
+	number := func(name string, value interface{}) (*float64, error) {
+		v, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("property %s is %v, expected a number", name, value)
+		}
+		return &v, nil
+	}
+	integer := func(name string, value interface{}) (*int, error) {
+		v, ok := value.(float64)
+		if !ok || v != math.Trunc(v) {
+			return nil, fmt.Errorf("property %s is %v, expected a whole number", name, value)
+		}
+		i := int(v)
+		return &i, nil
+	}
+
+	var err error
+	for name, value := range props {
+		switch name {
+		case "speed":
+			p.Speed, err = number(name, value)
+		case "stunDuration":
+			p.StunDuration, err = number(name, value)
+		case "pushPower":
+			p.PushPower, err = number(name, value)
+		case "patrolLeft":
+			p.PatrolLeft, err = integer(name, value)
+		case "patrolRight":
+			p.PatrolRight, err = integer(name, value)
+		case "target":
+			v, ok := value.(bool)
+			if !ok {
+				err = fmt.Errorf("property %s is %v, expected a bool", name, value)
+			}
+			p.Target = &v
+		case "off":
+			v, ok := value.(bool)
+			if !ok {
+				err = fmt.Errorf("property %s is %v, expected a bool", name, value)
+			}
+			p.Off = v
+		case "link":
+			v, ok := value.(string)
+			if !ok {
+				err = fmt.Errorf("property %s is %v, expected a string", name, value)
+			}
+			p.Link = v
+		default:
+			err = fmt.Errorf("unknown property %q", name)
+		}
+		if err != nil {
+			return Properties{}, err
+		}
+	}
+	return p, nil
+}
+
+// decodeTiledData decodes the tiles of a layer stored as CSV or as base64 with optional gzip or zlib compression.
+func decodeTiledData(data, encoding, compression string) ([]uint32, error) {
+	switch encoding {
+	case "csv":
+		var gids []uint32
+		for _, field := range strings.FieldsFunc(data, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t' }) {
+			var gid uint32
+			if _, err := fmt.Sscan(field, &gid); err != nil {
+				return nil, fmt.Errorf("invalid tile %q in csv data", field)
+			}
+			gids = append(gids, gid)
+		}
+		return gids, nil
+	case "base64":
+	case "":
+		return nil, fmt.Errorf("xml tile data is not supported, save the map with csv or base64 layers")
+	default:
+		return nil, fmt.Errorf("%q tile data is not supported", encoding)
+	}
+
+	if compression != "" && compression != "gzip" && compression != "zlib" {
+		return nil, fmt.Errorf("%q compressed tile data is not supported, use gzip, zlib or none", compression)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 tile data %w", err)
+	}
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "gzip":
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, fmt.Errorf("invalid gzip tile data %w", err)
+		}
+	case "zlib":
+		if r, err = zlib.NewReader(r); err != nil {
+			return nil, fmt.Errorf("invalid zlib tile data %w", err)
+		}
+	}
+	raw, err = io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cant decompress tile data %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("tile data has %d bytes, not a multiple of 4", len(raw))
+	}
+
+	gids := make([]uint32, len(raw)/4)
+	for i := range gids {
+		gids[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return gids, nil
+}
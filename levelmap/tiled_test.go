@@ -0,0 +1,247 @@
+package levelmap
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadTiled(t *testing.T) {
+	speed, patrolLeft, target := 2.5, 1, true
+	row := func(components string) []Component {
+		var tiles []Component
+		for _, c := range []byte(components) {
+			tiles = append(tiles, Component(c))
+		}
+		return tiles
+	}
+	want := &Map{
+		Tiles: [][]Component{
+			row("      "),
+			row("    H "),
+			row("   SH "),
+			row("XXXXXX"),
+		},
+		Textures: [][]Tile{
+			make([]Tile, 6),
+			make([]Tile, 6),
+			make([]Tile, 6),
+			{TileGroundMid, TileGroundMid, TileGroundMid, TileGroundMid, TileGroundFill, TileGroundMid},
+		},
+		Decor: [][]Tile{
+			{TileGroundMid, "", "", "", "", ""},
+			make([]Tile, 6),
+			make([]Tile, 6),
+			make([]Tile, 6),
+		},
+		Objects: []Object{
+			{Type: ObjectPlayer, X: 0, Y: 2},
+			{Type: ObjectGoal, X: 5, Y: 2},
+			{Type: ObjectNpc, X: 1, Y: 2, Props: Properties{Speed: &speed, PatrolLeft: &patrolLeft, Target: &target}},
+			{Type: ObjectDoor, X: 2, Y: 2, Props: Properties{Link: "red"}},
+		},
+	}
+
+	// the tmx map uses an external .tsx tileset and zlib, the tmj one an embedded and a .tsj tileset and gzip
+	for _, name := range []string{"level.tmx", "level.tmj"} {
+		m, err := Load(os.DirFS("testdata"), name)
+		if err != nil {
+			t.Errorf("%s %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(m, want) {
+			t.Errorf("%s loaded to\n%+v\nwant\n%+v", name, m, want)
+		}
+	}
+}
+
+// tiledDoc is a 2 by 2 tmj map with one tile layer and an embedded tileset, tile 1 is ground and tile 2 a ladder.
+func tiledDoc() map[string]interface{} {
+	return map[string]interface{}{
+		"orientation": "orthogonal",
+		"width":       2,
+		"height":      2,
+		"tilewidth":   10,
+		"tileheight":  10,
+		"tilesets": []interface{}{map[string]interface{}{
+			"firstgid": 1,
+			"name":     "tiles",
+			"tiles": []interface{}{
+				map[string]interface{}{"id": 0, "image": "Tile_07.png"},
+				map[string]interface{}{"id": 1, "image": "Ladder.png"},
+			},
+		}},
+		"layers": []interface{}{tiledTiles("terrain", 0, 0, 1, 1)},
+	}
+}
+
+func tiledTiles(name string, gids ...uint32) map[string]interface{} {
+	return map[string]interface{}{"type": "tilelayer", "name": name, "data": gids}
+}
+
+func tiledObjectsLayer(objects ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "objectgroup", "name": "objects", "objects": objects}
+}
+
+func tiledProperty(name, typ string, value interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{"name": name, "type": typ, "value": value}}
+}
+
+func propertyTile(props []interface{}) map[string]interface{} {
+	return map[string]interface{}{"id": 2, "image": "other.png", "properties": props}
+}
+
+func addLayers(doc map[string]interface{}, layers ...interface{}) {
+	doc["layers"] = append(doc["layers"].([]interface{}), layers...)
+}
+
+func addTile(doc map[string]interface{}, tile map[string]interface{}) {
+	tileset := doc["tilesets"].([]interface{})[0].(map[string]interface{})
+	tileset["tiles"] = append(tileset["tiles"].([]interface{}), tile)
+}
+
+func TestLoadTiledErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(doc map[string]interface{})
+		want string
+	}{
+		{"isometric", func(doc map[string]interface{}) { doc["orientation"] = "isometric" }, "only orthogonal"},
+		{"infinite", func(doc map[string]interface{}) { doc["infinite"] = true }, "infinite maps"},
+		{"too small", func(doc map[string]interface{}) { doc["height"] = 1 }, "at least 1 by 2"},
+		{"missing tiles", func(doc map[string]interface{}) {
+			doc["layers"] = []interface{}{tiledTiles("terrain", 0, 1, 1)}
+		}, "has 3 tiles, expected 4"},
+		{"flipped tile", func(doc map[string]interface{}) {
+			doc["layers"] = []interface{}{tiledTiles("terrain", 0, 0, 1|0x80000000, 1)}
+		}, "flipped"},
+		{"gid outside the tilesets", func(doc map[string]interface{}) {
+			doc["tilesets"].([]interface{})[0].(map[string]interface{})["firstgid"] = 2
+		}, "in no tileset"},
+		{"unknown tile image", func(doc map[string]interface{}) {
+			addTile(doc, map[string]interface{}{"id": 2, "image": "other.png"})
+			addLayers(doc, tiledTiles("more", 3, 0, 0, 0))
+		}, "not a known tile"},
+		{"component is not terrain", func(doc map[string]interface{}) {
+			addTile(doc, propertyTile(tiledProperty("component", "string", "P")))
+			addLayers(doc, tiledTiles("more", 3, 0, 0, 0))
+		}, "expected a terrain character"},
+		{"unknown tile property", func(doc map[string]interface{}) {
+			addTile(doc, propertyTile(tiledProperty("tile", "string", "Moss")))
+			addLayers(doc, tiledTiles("more", 3, 0, 0, 0))
+		}, "unknown tile"},
+		{"overlapping terrain", func(doc map[string]interface{}) {
+			addLayers(doc, tiledTiles("more", 0, 0, 0, 2))
+		}, "covers terrain"},
+		{"decor without texture", func(doc map[string]interface{}) {
+			addLayers(doc, tiledTiles("decor", 2, 0, 0, 0))
+		}, "no texture for the decoration"},
+		{"unknown object type", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "dragon"}))
+		}, "unknown type"},
+		{"polygon object", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "goal", "polygon": []interface{}{}}))
+		}, "polygon"},
+		{"unknown object property", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "npc", "properties": tiledProperty("color", "string", "red")}))
+		}, "unknown property"},
+		{"property of the wrong type", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "npc", "properties": tiledProperty("speed", "string", "fast")}))
+		}, "expected a number"},
+		{"fractional patrol", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "npc", "properties": tiledProperty("patrolLeft", "float", 1.5)}))
+		}, "expected a whole number"},
+		{"class property", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "npc", "properties": tiledProperty("stats", "class", map[string]interface{}{})}))
+		}, "class"},
+		{"object outside the map", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(map[string]interface{}{"id": 1, "type": "goal", "x": 25, "y": 5}))
+		}, "outside of the level"},
+		{"two players", func(doc map[string]interface{}) {
+			addLayers(doc, tiledObjectsLayer(
+				map[string]interface{}{"id": 1, "type": "player", "x": 5, "y": 5},
+				map[string]interface{}{"id": 2, "type": "spawn", "x": 15, "y": 5},
+			))
+		}, "2 players"},
+		{"image layer", func(doc map[string]interface{}) {
+			addLayers(doc, map[string]interface{}{"type": "imagelayer", "name": "sky"})
+		}, "imagelayer layers are not supported"},
+		{"zstd data", func(doc map[string]interface{}) {
+			doc["layers"] = []interface{}{map[string]interface{}{"type": "tilelayer", "name": "terrain", "encoding": "base64", "compression": "zstd", "data": "AAAA"}}
+		}, `"zstd" compressed`},
+		{"broken base64", func(doc map[string]interface{}) {
+			doc["layers"] = []interface{}{map[string]interface{}{"type": "tilelayer", "name": "terrain", "encoding": "base64", "data": "not base64"}}
+		}, "invalid base64"},
+		{"missing tileset file", func(doc map[string]interface{}) {
+			doc["tilesets"] = []interface{}{map[string]interface{}{"firstgid": 1, "source": "missing.tsj"}}
+		}, "cant read tileset"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := tiledDoc()
+			test.edit(doc)
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = LoadTiled(fstest.MapFS{"map.tmj": {Data: data}}, "map.tmj")
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadTiledErrorPosition(t *testing.T) {
+	doc := tiledDoc()
+	addLayers(doc, tiledTiles("more", 0, 0, 0, 2))
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadTiled(fstest.MapFS{"map.tmj": {Data: data}}, "map.tmj")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 || parseErr.Column != 1 {
+		t.Errorf("got error %v, want one at line 1 column 1", err)
+	}
+}
+
+func TestLoadTMXErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer string
+		want  string
+	}{
+		{"xml data", `<layer name="terrain"><data><tile gid="1"/></data></layer>`, "xml tile data is not supported"},
+		{"bad csv", `<layer name="terrain"><data encoding="csv">0,a,1,1</data></layer>`, "invalid tile"},
+		{"bad int property", `<objectgroup name="objects"><object id="1" type="npc"><properties><property name="patrolLeft" type="int" value="x"/></properties></object></objectgroup>`, "expected a number"},
+		{"tileset refers to another", "", "refers to another tileset"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tileset := `<tileset firstgid="1" name="tiles"><tile id="0"><image source="Tile_07.png"/></tile></tileset>`
+			if test.layer == "" {
+				tileset = `<tileset firstgid="1" source="nested.tsx"/>`
+			}
+			tmx := `<map orientation="orthogonal" width="2" height="2" tilewidth="10" tileheight="10">` + tileset + test.layer + `</map>`
+			fsys := fstest.MapFS{
+				"map.tmx":    {Data: []byte(tmx)},
+				"nested.tsx": {Data: []byte(`<tileset name="nested" source="other.tsx"/>`)},
+			}
+			_, err := LoadTiled(fsys, "map.tmx")
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+
+	if _, err := LoadTiled(fstest.MapFS{"map.txt": {}}, "map.txt"); err == nil {
+		t.Error("a .txt file was read as a tiled map")
+	}
+}
@@ -0,0 +1,202 @@
+package levelmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+type tmjMap struct {
+	Orientation string       `json:"orientation"`
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	TileWidth   int          `json:"tilewidth"`
+	TileHeight  int          `json:"tileheight"`
+	Infinite    bool         `json:"infinite"`
+	Tilesets    []tmjTileset `json:"tilesets"`
+	Layers      []tmjLayer   `json:"layers"`
+}
+
+type tmjTileset struct {
+	FirstGID int       `json:"firstgid"`
+	Source   string    `json:"source"`
+	Name     string    `json:"name"`
+	Tiles    []tmjTile `json:"tiles"`
+}
+
+type tmjTile struct {
+	ID         int           `json:"id"`
+	Image      string        `json:"image"`
+	Properties []tmjProperty `json:"properties"`
+}
+
+type tmjProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Properties  []tmjProperty   `json:"properties"`
+	Data        json.RawMessage `json:"data"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Objects     []tmjObject     `json:"objects"`
+	Layers      []tmjLayer      `json:"layers"`
+}
+
+type tmjObject struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	Class      string          `json:"class"`
+	X          float64         `json:"x"`
+	Y          float64         `json:"y"`
+	Width      float64         `json:"width"`
+	Height     float64         `json:"height"`
+	GID        uint32          `json:"gid"`
+	Properties []tmjProperty   `json:"properties"`
+	Polygon    json.RawMessage `json:"polygon"`
+	Polyline   json.RawMessage `json:"polyline"`
+}
+
+func decodeTMJ(data []byte, fsys fs.FS, dir string) (*tiledMap, error) {
+	var doc tmjMap
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	tm := &tiledMap{
+		orientation: doc.Orientation,
+		width:       doc.Width,
+		height:      doc.Height,
+		tileWidth:   doc.TileWidth,
+		tileHeight:  doc.TileHeight,
+		infinite:    doc.Infinite,
+	}
+	for _, ts := range doc.Tilesets {
+		tileset, err := tmjTilesetOf(ts, ts.FirstGID, fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		tm.tilesets = append(tm.tilesets, tileset)
+	}
+
+	layers, err := tmjLayers(doc.Layers)
+	if err != nil {
+		return nil, err
+	}
+	tm.layers = layers
+	return tm, nil
+}
+
+// decodeTSJ reads an external tileset saved as .tsj.
+func decodeTSJ(data []byte, firstGID int) (tiledTileset, error) {
+	var doc tmjTileset
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return tiledTileset{}, err
+	}
+	if doc.Source != "" {
+		return tiledTileset{}, fmt.Errorf("tileset %q refers to another tileset", doc.Name)
+	}
+	return tmjTilesetOf(doc, firstGID, nil, "")
+}
+
+func tmjTilesetOf(ts tmjTileset, firstGID int, fsys fs.FS, dir string) (tiledTileset, error) {
+	if ts.Source != "" {
+		return readTileset(fsys, path.Join(dir, ts.Source), firstGID)
+	}
+
+	tileset := tiledTileset{firstGID: firstGID, name: ts.Name, tiles: map[int]tiledTileInfo{}}
+	for _, tile := range ts.Tiles {
+		props, err := tmjProperties(tile.Properties)
+		if err != nil {
+			return tiledTileset{}, fmt.Errorf("tile %d of tileset %q %w", tile.ID, ts.Name, err)
+		}
+		tileset.tiles[tile.ID] = tiledTileInfo{image: tile.Image, props: props}
+	}
+	return tileset, nil
+}
+
+func tmjLayers(elems []tmjLayer) ([]tiledLayer, error) {
+	var layers []tiledLayer
+	for _, elem := range elems {
+		props, err := tmjProperties(elem.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("layer %q %w", elem.Name, err)
+		}
+		layer := tiledLayer{name: elem.Name, kind: elem.Type, props: props}
+
+		switch elem.Type {
+		case "tilelayer":
+			if layer.gids, err = tmjData(elem); err != nil {
+				return nil, fmt.Errorf("layer %q %w", elem.Name, err)
+			}
+		case "objectgroup":
+			for _, obj := range elem.Objects {
+				props, err := tmjProperties(obj.Properties)
+				if err != nil {
+					return nil, fmt.Errorf("layer %q object %d %w", elem.Name, obj.ID, err)
+				}
+				typ := obj.Class
+				if typ == "" {
+					typ = obj.Type
+				}
+				layer.objects = append(layer.objects, tiledObject{
+					id:     obj.ID,
+					typ:    typ,
+					x:      obj.X,
+					y:      obj.Y,
+					w:      obj.Width,
+					h:      obj.Height,
+					gid:    obj.GID,
+					points: obj.Polygon != nil || obj.Polyline != nil,
+					props:  props,
+				})
+			}
+		case "group":
+			if layer.layers, err = tmjLayers(elem.Layers); err != nil {
+				return nil, fmt.Errorf("group %q %w", elem.Name, err)
+			}
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// tmjData decodes the tiles of a layer, stored as an array of gids or as a base64 string.
+func tmjData(layer tmjLayer) ([]uint32, error) {
+	if layer.Data == nil {
+		return nil, fmt.Errorf("has no tile data")
+	}
+	if layer.Encoding == "base64" {
+		var data string
+		if err := json.Unmarshal(layer.Data, &data); err != nil {
+			return nil, fmt.Errorf("invalid base64 tile data %w", err)
+		}
+		return decodeTiledData(data, layer.Encoding, layer.Compression)
+	}
+	if layer.Encoding != "" && layer.Encoding != "csv" {
+		return nil, fmt.Errorf("%q tile data is not supported", layer.Encoding)
+	}
+
+	var gids []uint32
+	if err := json.Unmarshal(layer.Data, &gids); err != nil {
+		return nil, fmt.Errorf("invalid tile data %w", err)
+	}
+	return gids, nil
+}
+
+// tmjProperties converts custom properties to a map, class properties are not supported.
+func tmjProperties(props []tmjProperty) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, p := range props {
+		if p.Type == "class" {
+			return nil, fmt.Errorf("property %s is a class, that is not supported", p.Name)
+		}
+		values[p.Name] = p.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,247 @@
+package levelmap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+	Tilesets    []tmxTileset  `xml:"tileset"`
+	Properties  []tmxProperty `xml:"properties>property"`
+	Layers      []tmxLayer    `xml:",any"`
+}
+
+type tmxTileset struct {
+	FirstGID int       `xml:"firstgid,attr"`
+	Source   string    `xml:"source,attr"`
+	Name     string    `xml:"name,attr"`
+	Tiles    []tmxTile `xml:"tile"`
+}
+
+type tmxTile struct {
+	ID         int           `xml:"id,attr"`
+	Image      tmxImage      `xml:"image"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+// tmxLayer is any layer element, groups keep their children in Layers in the order of the file.
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Data       tmxData       `xml:"data"`
+	Objects    []tmxObject   `xml:"object"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Polygon    *struct{}     `xml:"polygon"`
+	Polyline   *struct{}     `xml:"polyline"`
+}
+
+func decodeTMX(data []byte, fsys fs.FS, dir string) (*tiledMap, error) {
+	var doc tmxMap
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	tm := &tiledMap{
+		orientation: doc.Orientation,
+		width:       doc.Width,
+		height:      doc.Height,
+		tileWidth:   doc.TileWidth,
+		tileHeight:  doc.TileHeight,
+		infinite:    doc.Infinite != 0,
+	}
+	for _, ts := range doc.Tilesets {
+		tileset, err := tmxTilesetOf(ts, ts.FirstGID, fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		tm.tilesets = append(tm.tilesets, tileset)
+	}
+
+	layers, err := tmxLayers(doc.Layers)
+	if err != nil {
+		return nil, err
+	}
+	tm.layers = layers
+	return tm, nil
+}
+
+// decodeTSX reads an external tileset saved as .tsx.
+func decodeTSX(data []byte, firstGID int) (tiledTileset, error) {
+	var doc tmxTileset
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return tiledTileset{}, err
+	}
+	if doc.Source != "" {
+		return tiledTileset{}, fmt.Errorf("tileset %q refers to another tileset", doc.Name)
+	}
+	return tmxTilesetOf(doc, firstGID, nil, "")
+}
+
+func tmxTilesetOf(ts tmxTileset, firstGID int, fsys fs.FS, dir string) (tiledTileset, error) {
+	if ts.Source != "" {
+		return readTileset(fsys, path.Join(dir, ts.Source), firstGID)
+	}
+
+	tileset := tiledTileset{firstGID: firstGID, name: ts.Name, tiles: map[int]tiledTileInfo{}}
+	for _, tile := range ts.Tiles {
+		props, err := tmxProperties(tile.Properties)
+		if err != nil {
+			return tiledTileset{}, fmt.Errorf("tile %d of tileset %q %w", tile.ID, ts.Name, err)
+		}
+		tileset.tiles[tile.ID] = tiledTileInfo{image: tile.Image.Source, props: props}
+	}
+	return tileset, nil
+}
+
+// readTileset reads an external tileset in either format.
+func readTileset(fsys fs.FS, name string, firstGID int) (tiledTileset, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return tiledTileset{}, fmt.Errorf("cant read tileset %w", err)
+	}
+
+	var tileset tiledTileset
+	switch path.Ext(name) {
+	case ".tsx":
+		tileset, err = decodeTSX(data, firstGID)
+	case ".tsj", ".json":
+		tileset, err = decodeTSJ(data, firstGID)
+	default:
+		return tiledTileset{}, fmt.Errorf("%s is not a tileset, expected .tsx or .tsj", name)
+	}
+	if err != nil {
+		return tiledTileset{}, fmt.Errorf("cant decode tileset %s %w", name, err)
+	}
+	return tileset, nil
+}
+
+func tmxLayers(elems []tmxLayer) ([]tiledLayer, error) {
+	var layers []tiledLayer
+	for _, elem := range elems {
+		layer := tiledLayer{name: elem.Name}
+		switch elem.XMLName.Local {
+		case "layer":
+			layer.kind = "tilelayer"
+		case "objectgroup":
+			layer.kind = "objectgroup"
+		case "group":
+			layer.kind = "group"
+		case "imagelayer":
+			layer.kind = "imagelayer"
+		default:
+			// editor settings and other elements that do not make up the level
+			continue
+		}
+
+		props, err := tmxProperties(elem.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("layer %q %w", elem.Name, err)
+		}
+		layer.props = props
+
+		switch layer.kind {
+		case "tilelayer":
+			if layer.gids, err = decodeTiledData(elem.Data.Text, elem.Data.Encoding, elem.Data.Compression); err != nil {
+				return nil, fmt.Errorf("layer %q %w", elem.Name, err)
+			}
+		case "objectgroup":
+			for _, obj := range elem.Objects {
+				props, err := tmxProperties(obj.Properties)
+				if err != nil {
+					return nil, fmt.Errorf("layer %q object %d %w", elem.Name, obj.ID, err)
+				}
+				typ := obj.Class
+				if typ == "" {
+					typ = obj.Type
+				}
+				layer.objects = append(layer.objects, tiledObject{
+					id:     obj.ID,
+					typ:    typ,
+					x:      obj.X,
+					y:      obj.Y,
+					w:      obj.Width,
+					h:      obj.Height,
+					gid:    obj.GID,
+					points: obj.Polygon != nil || obj.Polyline != nil,
+					props:  props,
+				})
+			}
+		case "group":
+			if layer.layers, err = tmxLayers(elem.Layers); err != nil {
+				return nil, fmt.Errorf("group %q %w", elem.Name, err)
+			}
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// tmxProperties converts custom properties to the values the json format would hold: numbers are float64.
+func tmxProperties(props []tmxProperty) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, p := range props {
+		value := p.Value
+		if value == "" {
+			value = strings.TrimSpace(p.Text)
+		}
+		switch p.Type {
+		case "int", "float", "object":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("property %s is %q, expected a number", p.Name, value)
+			}
+			values[p.Name] = v
+		case "bool":
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("property %s is %q, expected a bool", p.Name, value)
+			}
+			values[p.Name] = v
+		case "class":
+			return nil, fmt.Errorf("property %s is a class, that is not supported", p.Name)
+		default:
+			values[p.Name] = value
+		}
+	}
+	return values, nil
+}
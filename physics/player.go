@@ -0,0 +1,54 @@
+// Package physics holds the tuning of how the player moves. It is plain data so the level tools can read the same
+// profiles as the game without importing ebiten.
+package physics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Player tunes how the player moves. Speeds are in unscaled pixels per tick and times in seconds.
+type Player struct {
+	Speed            float64 `json:"speed"`
+	JumpImpulse      float64 `json:"jumpImpulse"`
+	JumpTicks        int     `json:"jumpTicks"`
+	Gravity          float64 `json:"gravity"`
+	TerminalVelocity float64 `json:"terminalVelocity"`
+	// CoyoteTime is how long after walking off a ledge a jump is still allowed.
+	CoyoteTime float64 `json:"coyoteTime"`
+	// JumpBuffer is how long a jump pressed in the air is remembered and performed on landing.
+	JumpBuffer float64 `json:"jumpBuffer"`
+	// JumpCutoff multiplies the upward velocity when jump is released before JumpTicks passed.
+	JumpCutoff float64 `json:"jumpCutoff"`
+	// AirControl is the share of the difference to the wanted horizontal speed applied per tick in the air,
+	// 1 turns instantly like on the ground.
+	AirControl float64 `json:"airControl"`
+}
+
+func DefaultPlayer() Player {
+	return Player{
+		Speed:            8,
+		JumpImpulse:      2,
+		JumpTicks:        5,
+		Gravity:          1,
+		TerminalVelocity: 20,
+		CoyoteTime:       0.1,
+		JumpBuffer:       0.1,
+		JumpCutoff:       0.5,
+		AirControl:       1,
+	}
+}
+
+// LoadPlayer reads a profile, fields missing in the file keep their default value.
+func LoadPlayer(filename string) (Player, error) {
+	p := DefaultPlayer()
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return p, fmt.Errorf("cant read player physics %w", err)
+	}
+	if err := json.Unmarshal(file, &p); err != nil {
+		return p, fmt.Errorf("cant parse player physics %s %w", filename, err)
+	}
+	return p, nil
+}